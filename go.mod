@@ -1,3 +1,10 @@
 module github.com/z0rr0/gopwgen
 
-go 1.15
+go 1.17
+
+require (
+	golang.org/x/crypto v0.11.0
+	golang.org/x/term v0.10.0
+)
+
+require golang.org/x/sys v0.13.0 // indirect
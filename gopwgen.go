@@ -6,11 +6,38 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"text/tabwriter"
 
 	"github.com/z0rr0/gopwgen/pwgen"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+// masterEnv is an environment variable of the master secret for site-specific passwords.
+const masterEnv = "GOPWGEN_MASTER"
+
+// subcommands
+const (
+	htpasswdCommand = "htpasswd" // generate passwords of htpasswd file users
+	checkCommand    = "check"    // check strength of passwords from stdin
+)
+
+// exit codes
+const (
+	exitArgs   = 1 // invalid arguments
+	exitConfig = 2 // invalid options or their files
+	exitRandom = 3 // failed random source
+	exitOutput = 4 // failed output
+	exitCheck  = 5 // checked passwords violate rules
+	exitBreach = 6 // failed breached passwords screening
 )
 
 func main() {
@@ -20,7 +47,13 @@ func main() {
 	numerals := flag.Bool("numerals", true,
 		"include at least one number in the password. This is the default option.")
 	oneLine := flag.Bool("one-line", false,
-		"print the generated passwords one per line.")
+		"print all generated passwords as one line.")
+	onePerLine := flag.Bool("one-per-line", false,
+		"print the generated passwords one per line. This is the default option if the output is not a terminal.")
+	columns := flag.Bool("columns", false,
+		"print the generated passwords in columns. This is the default option if the output is a terminal.")
+	length := flag.Int("length", 0, "length of passwords, it has priority over the positional argument.")
+	number := flag.Int("number", 0, "number of passwords to generate, it has priority over the positional argument.")
 	noCapitalize := flag.Bool("no-capitalize", false,
 		"don't bother to include any capital letters in the generated passwords.")
 	symbols := flag.Bool("symbols", false,
@@ -28,7 +61,7 @@ func main() {
 	noVowels := flag.Bool("no-vowels", false,
 		"Generate random passwords that do not contain vowels or numbers that might be mistaken for vowels. "+
 			"It provides less secure passwords to allow system administrators to not have to worry "+
-			"with random passwords acciden‐tally contain offensive substrings.")
+			"with random passwords acciden‐tally contain offensive substrings, see also -no-offensive.")
 	secure := flag.Bool("secure", false,
 		"generate completely random, hard-to-memorize passwords. These should only be used for machine "+
 			"passwords,  since otherwise  it's almost guaranteed that users will simply write the password on a "+
@@ -40,44 +73,389 @@ func main() {
 			"but in general use of this option is not recommended.")
 	removeChars := flag.String("remove-chars", "",
 		"don't use the specified characters in password. "+
-			"This option will disable the phomeme-based generator and uses the random password generator.")
+			"This option will disable the phoneme-based generator and uses the random password generator.")
 	sha1File := flag.String("sha1", "",
-		"will use the sha1's hash of given file and the optional seed to create password."+
+		"will use the sha1's hash of given file and the optional seed after the last '#' to create password, "+
+			"the whole value is used as a file name if such file exists. "+
 			"It will allow you to compute the same password later, if you remember the file, seed, "+
-			"and pwgen's options used. ie: pwgen -H ~/your_favorite.mp3#your@email.com "+
+			"and pwgen's options used. ie: gopwgen -sha1 ~/your_favorite.mp3#your@email.com "+
 			"gives a list of possibles passwords for your pop3 account, and you can ask this list again and again."+
 			"\n\nWARNING: The  passwords  generated  using this option are not very random."+
 			"If you use this option, make sure the attacker can not obtain a copy of the file."+
 			"Also, note that the name of the file may be easily available from the ~/.history or ~/.bash_history file.")
-	flag.Parse()
+	words := flag.Int("words", 0,
+		"generate passphrases of the specified number of random words instead of passwords. "+
+			"Words are capitalized unless -no-capitalize is used, "+
+			"a digit and a special character are added according to -numerals and -symbols options.")
+	wordList := flag.String("wordlist", pwgen.WordListLarge,
+		"word list for passphrases: \""+pwgen.WordListLarge+"\", \""+pwgen.WordListShort+"\" "+
+			"or a path to a file with one word per line.")
+	separator := flag.String("separator", "-", "words separator of passphrases.")
+	pattern := flag.String("pattern", "",
+		"generate passwords matching the pattern, chars: c/C - lower/upper consonant, v/V - lower/upper vowel, "+
+			"l/L - lower/upper letter, 9 - digit, s - special character, S - any of them, "+
+			"{n} - repeat the previous element n times, \\ - escape of the next char, other chars are used as is. "+
+			"Removed and ambiguous chars are excluded, ie: -pattern 'Cvccvc-9{2}-Ss'")
+	policy := flag.String("policy", "",
+		"constraints of random passwords as comma separated key=value pairs, keys: "+
+			"min-lowers, max-lowers, min-uppers, max-uppers, min-digits, max-digits, min-symbols, max-symbols, "+
+			"min-length, max-length, max-consecutive and forbidden-first, its commas and backslashes are escaped by a backslash. "+
+			"It disables the phoneme-based generator, "+
+			"ie: -policy 'min-uppers=2,min-digits=2,max-consecutive=1,forbidden-first=0123456789'")
+	presetName := flag.String("preset", "",
+		"use the named preset of length, alphabet and policy for a target system, see -presets.")
+	presetFile := flag.String("preset-file", "",
+		"load additional presets from a JSON file with an array of objects like "+
+			`{"name": "app", "length": 20, "symbols": true, "remove_chars": "'", "policy": {"min_digits": 2}}.`)
+	listPresets := flag.Bool("presets", false, "print available presets and exit.")
+	entropy := flag.Bool("entropy", false,
+		"print estimated bits of entropy of the generated passwords to stderr, it's an upper bound for pronounceable ones.")
+	site := flag.String("site", "",
+		"derive deterministic passwords for the site from the master secret, -login and -counter. "+
+			"The master secret is read from "+masterEnv+" environment variable or stdin, "+
+			"one password is generated by default. The same secret, site, login, counter and options "+
+			"give the same passwords, ie: gopwgen -site example.com -login admin -secure 20")
+	login := flag.String("login", "", "user name of site-specific passwords, see -site.")
+	counter := flag.Int("counter", 1, "version of site-specific passwords, increase it to change a password, see -site.")
+	seed := flag.Int64("seed", 0,
+		"seed of reproducible passwords, the same seed and options give the same passwords "+
+			"with any Go version. It must not be used for real passwords.")
+	format := flag.String("format", pwgen.FormatText,
+		"output format: "+pwgen.FormatText+", "+pwgen.FormatJSON+", "+pwgen.FormatNDJSON+" or "+pwgen.FormatCSV+".")
+	metadata := flag.Bool("metadata", false,
+		"include length, alphabet size, entropy and its upper bound flag of every password to json, ndjson and csv output.")
+	hash := flag.String("hash", "",
+		"print every password with its hash for provisioning: "+pwgen.HashBcrypt+", "+pwgen.HashSHA512+", "+
+			pwgen.HashArgon2id+", "+pwgen.HashAPR1+" or "+pwgen.HashSHA1+" (htpasswd {SHA}).")
+	noOffensive := flag.Bool("no-offensive", false,
+		"reject and generate again passwords with offensive words of the embedded list, "+
+			"they are matched case-insensitively after l33t normalization like 0 - o, 1 - i. "+
+			"Unlike -no-vowels it keeps the full alphabet.")
+	blocklist := flag.String("blocklist", "",
+		"file of words which are rejected in passwords like -no-offensive ones, one word per line.")
+	breached := flag.String("breached", "",
+		"reject and generate again passwords found in a local Pwned Passwords SHA-1 file of sorted "+
+			"\"HASH:COUNT\" lines or a directory of range files named by 5 chars hash prefixes.")
+	workers := flag.Int("workers", 1,
+		"number of goroutines generating passwords and their hashes, 0 is the number of CPUs. "+
+			"Seeded and other deterministic passwords depend on it.")
+	unordered := flag.Bool("unordered", false,
+		"print passwords of parallel -workers as soon as they are generated.")
+	users := flag.String("users", "",
+		"htpasswd mode: file with user names one per line, \"-\" is stdin. They are added to positional ones.")
+	credentials := flag.String("credentials", "",
+		"htpasswd mode: file of cleartext \"user:password\" credentials, they are printed to stdout by default.")
+	command, cmdArgs := "", os.Args[1:]
+	if len(cmdArgs) > 0 && (cmdArgs[0] == htpasswdCommand || cmdArgs[0] == checkCommand) {
+		command, cmdArgs = cmdArgs[0], cmdArgs[1:]
+	}
+	cmdArgs, err := pwgen.ExpandArgs(flag.CommandLine, cmdArgs)
+	if err != nil {
+		fail(exitArgs, "%v", err)
+	}
+	// flag.CommandLine exits with code 2 of exitConfig on errors, so they are reported as invalid arguments
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+	err = flag.CommandLine.Parse(cmdArgs)
+	flag.CommandLine.SetOutput(os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		*help = true
+	case err != nil:
+		fail(exitArgs, "%v", err)
+	}
 
 	if *help {
 		fmt.Print("GoPwgen - generate pronounceable passwords\n\n")
+		fmt.Print("Usage: gopwgen [options] [length] [number]\n")
+		fmt.Print("       gopwgen htpasswd [options] file [user ...]\n")
+		fmt.Print("       gopwgen check [options] [length] < passwords\n\n")
+		fmt.Print("The htpasswd mode generates a password for every user, adds or updates them in htpasswd file " +
+			"with -hash scheme (" + pwgen.HashBcrypt + " by default, " + pwgen.HashAPR1 + ", " + pwgen.HashSHA512 + " or " +
+			pwgen.HashSHA1 + ") and prints cleartext credentials.\n")
+		fmt.Print("The check mode reads passwords one per line and reports their composition, entropy, " +
+			"weak patterns, score from 0 to 4 and violations of the options rules in -format.\n\n")
 		flag.PrintDefaults()
+		fmt.Print("\nShort options of the original pwgen: -0 -1 -A -a -B -c -C -h -H file[#seed] " +
+			"-n -N number -r chars -s -v -y, they can be combined like -sy1.\n")
+		fmt.Print("\nExit codes: 1 - invalid arguments, 2 - invalid options, 3 - failed random source, 4 - failed output, " +
+			"5 - checked passwords violate rules, 6 - failed breached passwords screening.\n")
+		return
+	}
+	presets := pwgen.DefaultPresets()
+	if *presetFile != "" {
+		if err := presets.LoadFile(*presetFile); err != nil {
+			fail(exitConfig, "%v", err)
+		}
+	}
+	if *listPresets {
+		if err := printPresets(presets); err != nil {
+			fail(exitOutput, "%v", err)
+		}
 		return
 	}
 	args := flag.Args()
-	pwLength, numPw, err := pwgen.ParseArgs(args)
+	numArgs := args
+	if command == htpasswdCommand {
+		numArgs = nil
+	}
+	pwLength, numPw, err := pwgen.ParseArgs(numArgs)
 	if err != nil {
-		_, err = fmt.Fprintln(os.Stderr, "ERROR: required integer arguments")
+		fail(exitArgs, "required integer arguments")
+	}
+	cfg := &pwgen.Config{
+		Length:       pwLength,
+		Number:       numPw,
+		RemoveChars:  *removeChars,
+		SHA1File:     *sha1File,
+		NoNumerals:   *noNumerals,
+		Numerals:     *numerals,
+		OneLine:      *oneLine,
+		OnePerLine:   *onePerLine,
+		Columns:      *columns,
+		NoCapitalize: *noCapitalize,
+		Ambiguous:    *ambiguous,
+		Symbols:      *symbols,
+		NoVowels:     *noVowels,
+		Secure:       *secure,
+		Words:        *words,
+		WordList:     *wordList,
+		Separator:    *separator,
+		Pattern:      *pattern,
+		Seed:         *seed,
+		Format:       *format,
+		Metadata:     *metadata,
+		Hash:         *hash,
+		BreachList:   *breached,
+		NoOffensive:  *noOffensive,
+		Blocklist:    *blocklist,
+		Workers:      *workers,
+		Unordered:    *unordered,
+	}
+	if *workers == 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if *site != "" {
+		cfg.Site, cfg.Login, cfg.Counter = *site, *login, *counter
+		cfg.Master, err = readMaster()
 		if err != nil {
-			panic(err)
+			fail(exitConfig, "can not read master secret: %v", err)
+		}
+		if len(args) < 2 {
+			cfg.Number = 1
 		}
-		os.Exit(1)
 	}
-	pg, err := pwgen.New(
-		pwLength, numPw, *removeChars, *sha1File,
-		*noNumerals, *numerals, *oneLine, *noCapitalize, *ambiguous, *symbols, *noVowels, *secure,
-	)
+	if *length > 0 {
+		cfg.Length = *length
+	}
+	if *number > 0 {
+		cfg.Number = *number
+	}
+	if *policy != "" {
+		cfg.Policy, err = pwgen.ParsePolicy(*policy)
+		if err != nil {
+			fail(exitConfig, "%v", err)
+		}
+	}
+	if *presetName != "" {
+		preset, ok := presets[*presetName]
+		if !ok {
+			fail(exitConfig, "unknown preset %q", *presetName)
+		}
+		preset.Apply(cfg)
+		switch {
+		case *length > 0:
+			cfg.Length = *length
+		case len(numArgs) > 0:
+			// explicit length has priority
+			cfg.Length = pwLength
+		}
+	}
+	switch command {
+	case htpasswdCommand:
+		runHtpasswd(cfg, args, *users, *credentials)
+		return
+	case checkCommand:
+		runCheck(cfg)
+		return
+	}
+	pg, err := pwgen.NewWithConfig(cfg)
 	if err != nil {
-		_, err = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		fail(exitConfig, "%v", err)
+	}
+	if *entropy {
+		format := "entropy: %.1f bits\n"
+		if pg.EntropyIsUpperBound() {
+			format = "entropy: at most %.1f bits (upper bound for pronounceable passwords)\n"
+		}
+		_, err = fmt.Fprintf(os.Stderr, format, pg.Entropy())
 		if err != nil {
-			panic(err)
+			fail(exitOutput, "%v", err)
 		}
-		os.Exit(2)
 	}
 	err = pg.Print(os.Stdout)
 	if err != nil {
-		panic(err)
+		failGenerate(exitOutput, err)
+	}
+}
+
+// failGenerate prints the error and exits with a code of the generation error.
+func failGenerate(code int, err error) {
+	fail(generateExitCode(code, err), "%v", err)
+}
+
+// generateExitCode returns an exit code of failed random source, breached passwords screening,
+// invalid options (blocked words, impossible policy, hash errors) or the default code for other errors.
+func generateExitCode(code int, err error) int {
+	var (
+		randomErr *pwgen.RandomError
+		breachErr *pwgen.BreachError
+		configErr *pwgen.ConfigError
+	)
+	switch {
+	case errors.As(err, &randomErr):
+		return exitRandom
+	case errors.As(err, &breachErr):
+		return exitBreach
+	case errors.As(err, &configErr), errors.Is(err, pwgen.ErrBlocked), errors.Is(err, pwgen.ErrPolicy),
+		errors.Is(err, pwgen.ErrHash), errors.Is(err, bcrypt.ErrPasswordTooLong):
+		return exitConfig
+	}
+	return code
+}
+
+// runHtpasswd generates passwords of users, updates htpasswd file and outputs credentials.
+func runHtpasswd(cfg *pwgen.Config, args []string, usersFile, credentialsFile string) {
+	if len(args) == 0 {
+		fail(exitArgs, "required htpasswd file")
+	}
+	name, users := args[0], args[1:]
+	if usersFile != "" {
+		fileUsers, err := readUsers(usersFile)
+		if err != nil {
+			fail(exitConfig, "can not read users: %v", err)
+		}
+		users = append(users, fileUsers...)
+	}
+	if len(users) == 0 {
+		fail(exitArgs, "required user names")
+	}
+	if cfg.Hash == "" {
+		cfg.Hash = pwgen.HashBcrypt
+	}
+	cfg.Number = len(users)
+	pg, err := pwgen.NewWithConfig(cfg)
+	if err != nil {
+		fail(exitConfig, "%v", err)
+	}
+	credentials, err := pg.Credentials(users)
+	if err != nil {
+		failGenerate(exitConfig, err)
+	}
+	// credentials are saved before the htpasswd update, so new passwords can not be lost
+	err = writeCredentials(credentialsFile, credentials)
+	if err == nil {
+		err = pwgen.UpdateHtpasswd(name, credentials)
+	}
+	// fail exits without deferred calls, so passwords are wiped before it
+	pwgen.DestroyCredentials(credentials)
+	if err != nil {
+		fail(exitOutput, "%v", err)
+	}
+}
+
+// runCheck outputs strength reports of passwords from stdin.
+func runCheck(cfg *pwgen.Config) {
+	pg, err := pwgen.NewWithConfig(cfg)
+	if err != nil {
+		fail(exitConfig, "%v", err)
+	}
+	violations, err := pg.CheckAll(os.Stdin, os.Stdout)
+	if err != nil {
+		fail(exitOutput, "%v", err)
+	}
+	if violations > 0 {
+		fail(exitCheck, "%d passwords violate rules", violations)
+	}
+}
+
+// readUsers returns user names from the file or stdin if the name is "-".
+func readUsers(name string) ([]string, error) {
+	if name == "-" {
+		return pwgen.ReadUsers(os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	users, err := pwgen.ReadUsers(f)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return nil, err
+	}
+	return users, f.Close()
+}
+
+// writeCredentials outputs cleartext credentials to the file or stdout if the name is empty.
+// The file is readable only by its owner.
+func writeCredentials(name string, credentials []pwgen.Credential) error {
+	if name == "" {
+		return pwgen.WriteCredentials(os.Stdout, credentials)
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = pwgen.WriteCredentials(f, credentials)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return err
+	}
+	return f.Close()
+}
+
+// fail prints the error message and exits with the code.
+func fail(code int, format string, a ...interface{}) {
+	// nothing can be done if stderr fails, the exit code is enough
+	_, _ = fmt.Fprintf(os.Stderr, "ERROR: "+format+"\n", a...)
+	os.Exit(code)
+}
+
+// readMaster returns the master secret from the environment variable or stdin,
+// it's read without echo from a terminal.
+func readMaster() ([]byte, error) {
+	if value, ok := os.LookupEnv(masterEnv); ok {
+		return []byte(value), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		_, err := fmt.Fprint(os.Stderr, "master secret: ")
+		if err != nil {
+			return nil, err
+		}
+		master, err := term.ReadPassword(fd)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fmt.Fprintln(os.Stderr)
+		return master, err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// printPresets outputs names, lengths and descriptions of presets.
+func printPresets(presets pwgen.Presets) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range presets.Names() {
+		p := presets[name]
+		_, err := fmt.Fprintf(w, "%s\t%d\t%s\n", p.Name, p.Length, p.Description)
+		if err != nil {
+			return err
+		}
 	}
+	return w.Flush()
 }
@@ -0,0 +1,38 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/z0rr0/gopwgen/pwgen"
+)
+
+func TestGenerateExitCode(t *testing.T) {
+	_, hashErr := pwgen.HashPassword(pwgen.HashBcrypt, bytes.Repeat([]byte("a"), 80))
+	if hashErr == nil {
+		t.Fatal("expected bcrypt error")
+	}
+	values := []struct {
+		err  error
+		code int
+	}{
+		{&pwgen.RandomError{Err: errors.New("failed")}, exitRandom},
+		{&pwgen.BreachError{Err: pwgen.ErrBreached}, exitBreach},
+		{pwgen.ErrBlocked, exitConfig},
+		{fmt.Errorf("%w: no password is found after 10000 attempts", pwgen.ErrPolicy), exitConfig},
+		{&pwgen.ConfigError{Field: "Hash", Err: pwgen.ErrHash}, exitConfig},
+		{hashErr, exitConfig},
+		{errors.New("write failed"), exitOutput},
+	}
+	for i, v := range values {
+		if code := generateExitCode(exitOutput, v.err); code != v.code {
+			t.Errorf("[%v] unexpected exit code %v for %v", i, code, v.err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ErrFlag is an error of an invalid pwgen-compatible flag.
+var ErrFlag = errors.New("invalid flag")
+
+// pwgenFlag is a replacement of the original pwgen's option.
+type pwgenFlag struct {
+	flags []string // flags names with values, ie: "no-capitalize=false"
+	value string   // flag name which gets an option value
+}
+
+// pwgenShortFlags are short options of the original pwgen.
+var pwgenShortFlags = map[byte]pwgenFlag{
+	'0': {flags: []string{"no-numerals"}},
+	'1': {flags: []string{"one-per-line"}},
+	'A': {flags: []string{"no-capitalize"}},
+	'a': {}, // alt-phonics, it's ignored by the original pwgen too
+	'B': {flags: []string{"ambiguous"}},
+	'c': {flags: []string{"no-capitalize=false"}},
+	'C': {flags: []string{"columns"}},
+	'h': {flags: []string{"help"}},
+	'H': {value: "sha1"},
+	'n': {flags: []string{"numerals", "no-numerals=false"}},
+	'N': {value: "number"},
+	'r': {value: "remove-chars"},
+	's': {flags: []string{"secure"}},
+	'v': {flags: []string{"no-vowels"}},
+	'y': {flags: []string{"symbols"}},
+}
+
+// pwgenLongFlags are long options of the original pwgen which differ from gopwgen ones.
+var pwgenLongFlags = map[string]pwgenFlag{
+	"alt-phonics":   {},
+	"capitalize":    {flags: []string{"no-capitalize=false"}},
+	"numerals":      {flags: []string{"numerals", "no-numerals=false"}},
+	"num-passwords": {value: "number"},
+}
+
+// takesValue returns true if the flag requires a value argument.
+func takesValue(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+// ExpandArgs converts command line arguments of the original pwgen to flags of the set.
+// It supports combined short options like "-sy1", "-N 5", "-N5", "-H file#seed",
+// GNU long options like "--remove-chars=abc", "--num-passwords 5"
+// and options after positional arguments. Flags of the set are kept as is.
+// Positional arguments are moved to the end after "--" terminator.
+func ExpandArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+		long := strings.HasPrefix(arg, "--")
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		if p, ok := pwgenLongFlags[name]; long && ok {
+			if p.value != "" && !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("%w: no value of --%s", ErrFlag, name)
+				}
+				i++
+				value = args[i]
+			}
+			flags = append(flags, p.expand(value)...)
+			continue
+		}
+		if f := fs.Lookup(name); f != nil || long {
+			// gopwgen flag or an unknown long one, which is reported by the set
+			flags = append(flags, "-"+strings.TrimLeft(arg, "-"))
+			if f != nil && takesValue(f) && !hasValue && i+1 < len(args) {
+				i++
+				flags = append(flags, args[i])
+			}
+			continue
+		}
+		// combined short options
+		for j := 1; j < len(arg); j++ {
+			p, ok := pwgenShortFlags[arg[j]]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown option -%c", ErrFlag, arg[j])
+			}
+			if p.value == "" {
+				flags = append(flags, p.expand("")...)
+				continue
+			}
+			value = arg[j+1:]
+			if value == "" {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("%w: no value of -%c", ErrFlag, arg[j])
+				}
+				i++
+				value = args[i]
+			}
+			flags = append(flags, p.expand(value)...)
+			break
+		}
+	}
+	if len(positional) > 0 {
+		flags = append(append(flags, "--"), positional...)
+	}
+	return flags, nil
+}
+
+// expand returns flags of the set for the option with the value.
+func (p pwgenFlag) expand(value string) []string {
+	result := make([]string, 0, len(p.flags)+1)
+	for _, name := range p.flags {
+		result = append(result, "-"+name)
+	}
+	if p.value != "" {
+		result = append(result, "-"+p.value+"="+value)
+	}
+	return result
+}
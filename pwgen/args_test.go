@@ -0,0 +1,91 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testFlagSet returns a set with gopwgen flags.
+func testFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	for _, name := range []string{
+		"help", "no-numerals", "numerals", "one-line", "one-per-line", "columns", "no-capitalize",
+		"symbols", "no-vowels", "secure", "ambiguous",
+	} {
+		fs.Bool(name, false, "")
+	}
+	for _, name := range []string{"remove-chars", "sha1", "pattern"} {
+		fs.String(name, "", "")
+	}
+	fs.Int("number", 0, "")
+	fs.Int("words", 0, "")
+	return fs
+}
+
+func TestExpandArgs(t *testing.T) {
+	values := []struct {
+		args, expected string
+	}{
+		{"", ""},
+		{"12 5", "-- 12 5"},
+		{"-secure -words 3 12", "-secure -words 3 -- 12"},
+		{"-sy1", "-secure -symbols -one-per-line"},
+		{"-s -y -B -c -n -0 -A -v -1 -C", "-secure -symbols -ambiguous -no-capitalize=false -numerals " +
+			"-no-numerals=false -no-numerals -no-capitalize -no-vowels -one-per-line -columns"},
+		{"-N 5 12", "-number=5 -- 12"},
+		{"-sN5 12", "-secure -number=5 -- 12"},
+		{"-syr abc 16", "-secure -symbols -remove-chars=abc -- 16"},
+		{"-Hfile.mp3#me@example.com", "-sha1=file.mp3#me@example.com"},
+		{"--remove-chars=abc --num-passwords=3 --capitalize --alt-phonics", "-remove-chars=abc -number=3 -no-capitalize=false"},
+		{"--num-passwords 3 --remove-chars abc --secure", "-number=3 -remove-chars abc -secure"},
+		{"--numerals --help", "-numerals -no-numerals=false -help"},
+		{"12 -s 3 -pattern a-b", "-secure -pattern a-b -- 12 3"},
+		{"-s -- -1 2", "-secure -- -1 2"},
+		{"--unknown", "-unknown"},
+	}
+	for i, v := range values {
+		args, err := ExpandArgs(testFlagSet(), strings.Fields(v.args))
+		if err != nil {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+			continue
+		}
+		if s := strings.Join(args, " "); s != v.expected {
+			t.Errorf("[%v] unexpected args %q", i, s)
+		}
+	}
+	for i, v := range []string{"-x", "-sx", "-N", "-sr", "--num-passwords"} {
+		_, err := ExpandArgs(testFlagSet(), strings.Fields(v))
+		if !errors.Is(err, ErrFlag) {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestExpandArgsParse(t *testing.T) {
+	fs := testFlagSet()
+	args, err := ExpandArgs(fs, strings.Fields("16 -sy -N 3 -r abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{
+		"secure": "true", "symbols": "true", "number": "3", "remove-chars": "abc",
+	} {
+		if s := fs.Lookup(name).Value.String(); s != expected {
+			t.Errorf("unexpected value %v=%v", name, s)
+		}
+	}
+	if s := strings.Join(fs.Args(), " "); s != "16" {
+		t.Errorf("unexpected positional args %v", s)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	_ "embed" // embedded offensive words
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+	//go:embed wordlists/blocklist.txt
+	offensiveList string
+	// offensiveWords is a lazy parsed list of offensive words.
+	offensiveWords = &embeddedWordList{data: offensiveList}
+
+	// ErrBlocked is an error of too many generated passwords which contain blocked words.
+	ErrBlocked = errors.New("too many passwords with blocked words are generated")
+)
+
+// Blocklist is a set of words which can't be substrings of passwords.
+// Words are matched case-insensitively after l33t normalization, ie: "sh1t" and "5H!T" contain "shit".
+type Blocklist struct {
+	words    map[string]struct{}
+	min, max int // lengths of words
+}
+
+// NewBlocklist returns a new blocklist of the words.
+// Offensive words of the embedded list are added if it's required.
+func NewBlocklist(offensive bool, words ...string) *Blocklist {
+	b := &Blocklist{words: make(map[string]struct{})}
+	if offensive {
+		b.Add(offensiveWords.list()...)
+	}
+	b.Add(words...)
+	return b
+}
+
+// LoadBlocklist returns a new blocklist of words from the file, one word per line,
+// offensive words of the embedded list are added if it's required.
+func LoadBlocklist(offensive bool, name string) (*Blocklist, error) {
+	b := NewBlocklist(offensive)
+	if name == "" {
+		return b, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	words, err := ReadWordList(f)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	b.Add(words...)
+	return b, nil
+}
+
+// Add adds the words to the blocklist, empty words are ignored.
+func (b *Blocklist) Add(words ...string) {
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		b.words[word] = struct{}{}
+		if n := len(word); b.min == 0 || n < b.min {
+			b.min = n
+		}
+		if n := len(word); n > b.max {
+			b.max = n
+		}
+	}
+}
+
+// Contains returns true if the password contains a blocked word.
+func (b *Blocklist) Contains(password string) bool {
+	return b.contains([]byte(password))
+}
+
+// contains is like Contains but checks the password buffer, normalized copies are wiped.
+func (b *Blocklist) contains(password []byte) bool {
+	if len(b.words) == 0 {
+		return false
+	}
+	variants := leetBytes(password)
+	defer func() {
+		for _, variant := range variants {
+			wipe(variant)
+		}
+	}()
+	for _, variant := range variants {
+		for i := 0; i+b.min <= len(variant); i++ {
+			for j := i + b.min; j <= len(variant) && j-i <= b.max; j++ {
+				if _, ok := b.words[string(variant[i:j])]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	b := NewBlocklist(true, "Gopher", " ")
+	values := map[string]bool{
+		"":          false,
+		"ha9iFohd":  false,
+		"xxSHITxx":  true,
+		"sh1t":      true,
+		"5H!T":      true,
+		"p|ss":      true,
+		"g0ph3r42":  true,
+		"gophe":     false,
+		"kaeTieg0":  false,
+		"Ahx4Sh1T9": true,
+	}
+	for password, expected := range values {
+		if b.Contains(password) != expected {
+			t.Errorf("unexpected result for %v", password)
+		}
+	}
+	if NewBlocklist(false).Contains("shit") {
+		t.Error("unexpected blocked word")
+	}
+}
+
+func TestNoOffensive(t *testing.T) {
+	name := path.Join(os.TempDir(), "pwgen_blocklist_test.txt")
+	err := os.WriteFile(name, []byte("# custom words\nAIPH\nohgh\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(name); err != nil {
+			t.Error(err)
+		}
+	}()
+	b, err := LoadBlocklist(true, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg, err := NewWithOptions(WithNumber(500), WithNoOffensive(true), WithBlocklist(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for password := range pg.Passwords() {
+		if b.Contains(password) {
+			t.Errorf("blocked password %v", password)
+		}
+		if !strings.ContainsAny(password, pwVowels) {
+			t.Errorf("no vowels in %v", password)
+		}
+	}
+	if v := strings.Join(pg.Check("Aiph3Sh1t").Violations, "; "); v != "blocked word" {
+		t.Errorf("unexpected violations %q", v)
+	}
+	// all passwords are blocked
+	err = os.WriteFile(name, []byte("0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg, err = NewWithOptions(WithPattern("9"), WithBlocklist(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pg.TryGenerate(); !errors.Is(err, ErrBlocked) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = NewWithOptions(WithBlocklist("/root/bad_123"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// breachPrefix is a length of SHA-1 prefix of range files names.
+	breachPrefix = 5
+	// breachBuffer is a size of read blocks, it's greater than two lines.
+	breachBuffer = 256
+)
+
+// ErrBreached is an error of too many generated passwords which are found in the breach list.
+var ErrBreached = errors.New("too many breached passwords are generated")
+
+// BreachError is an error of breached passwords screening.
+type BreachError struct {
+	Err error
+}
+
+// Error returns a text of the screening error.
+func (e *BreachError) Error() string {
+	return fmt.Sprintf("breached passwords screening failed: %v", e.Err)
+}
+
+// Unwrap returns an original error.
+func (e *BreachError) Unwrap() error {
+	return e.Err
+}
+
+// BreachList is a local copy of Pwned Passwords SHA-1 hashes.
+// It's a file of "HASH:COUNT" lines sorted by hashes or a directory of range files,
+// where every file is named by 5 chars prefix of hashes and contains "SUFFIX:COUNT" lines
+// sorted by suffixes. Hex digits are case-insensitive.
+// Files are searched by the binary search, so they are not loaded to memory.
+type BreachList struct {
+	name string
+	dir  bool
+}
+
+// OpenBreachList returns a new breach list of the file or range files directory.
+func OpenBreachList(name string) (*BreachList, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &BreachList{name: name, dir: info.IsDir()}, nil
+}
+
+// Contains returns true if SHA-1 hash of the password is in the list.
+func (b *BreachList) Contains(password string) (bool, error) {
+	return b.contains([]byte(password))
+}
+
+// contains is like Contains but checks the password buffer.
+func (b *BreachList) contains(password []byte) (bool, error) {
+	sum := sha1.Sum(password)
+	key := bytes.ToUpper([]byte(hex.EncodeToString(sum[:])))
+	name := b.name
+	if b.dir {
+		name, key = b.rangeFile(string(key[:breachPrefix])), key[breachPrefix:]
+		if name == "" {
+			return false, nil
+		}
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	found, err := searchSorted(f, key)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return false, err
+	}
+	return found, f.Close()
+}
+
+// rangeFile returns a name of the existing range file of the prefix or an empty string.
+func (b *BreachList) rangeFile(prefix string) string {
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		name = filepath.Join(b.name, name)
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// searchSorted returns true if the file contains a line starting with the key and ':'.
+// Lines are sorted by keys, so a binary search by byte offsets is used.
+func searchSorted(f *os.File, key []byte) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	// search the first offset where the next line has a key not less than the required one
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, err := lineAt(f, mid, len(key))
+		if err != nil {
+			return false, err
+		}
+		if line == nil || bytes.Compare(line, key) >= 0 {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	line, err := lineAt(f, lo, len(key))
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(line, key), nil
+}
+
+// lineAt returns an upper case key of the first line which starts at the offset or later.
+// It returns nil if there are no such lines.
+func lineAt(f *os.File, offset int64, size int) ([]byte, error) {
+	buf := make([]byte, breachBuffer)
+	start := 0
+	if offset > 0 {
+		// the line starts after a new line char, it can be the previous one
+		offset--
+		start = -1
+	}
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+	if start < 0 {
+		start = bytes.IndexByte(buf, '\n') + 1
+		if start == 0 {
+			return nil, nil
+		}
+	}
+	line := buf[start:]
+	if i := bytes.IndexAny(line, ":\r\n"); i >= 0 {
+		line = line[:i]
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if len(line) > size {
+		line = line[:size]
+	}
+	return bytes.ToUpper(line), nil
+}
+
+// screen returns true if the password is not found in the breach list.
+func (pg *PwGen) screen(password []byte) (bool, error) {
+	if pg.breaches == nil {
+		return true, nil
+	}
+	breached, err := pg.breaches.contains(password)
+	if err != nil {
+		return false, &BreachError{Err: err}
+	}
+	return !breached, nil
+}
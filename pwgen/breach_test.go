@@ -0,0 +1,144 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sha1Hex returns upper case SHA-1 hex hash of the password.
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachFiles creates a sorted hashes file and a range files directory of the passwords.
+func writeBreachFiles(t *testing.T, dir string, passwords []string) (string, string) {
+	hashes := make([]string, 0, len(passwords)+200)
+	for _, p := range passwords {
+		hashes = append(hashes, sha1Hex(p))
+	}
+	for i := 0; i < 200; i++ {
+		hashes = append(hashes, sha1Hex(fmt.Sprintf("filler-%d", i)))
+	}
+	sort.Strings(hashes)
+	var b strings.Builder
+	ranges := path.Join(dir, "ranges")
+	if err := os.Mkdir(ranges, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for i, h := range hashes {
+		line := fmt.Sprintf("%s:%d\r\n", h, i*7919%100000+1)
+		b.WriteString(line)
+		f, err := os.OpenFile(path.Join(ranges, h[:5]+".txt"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.WriteString(strings.ToLower(line[5:])); err != nil {
+			t.Fatal(err)
+		}
+		if err = f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := path.Join(dir, "pwned.txt")
+	if err := os.WriteFile(name, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name, ranges
+}
+
+func TestBreachList(t *testing.T) {
+	dir, err := os.MkdirTemp("", "pwgen_breach_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	breached := []string{"password", "123456", "ha9iFohd", "kaeTieg0"}
+	name, ranges := writeBreachFiles(t, dir, breached)
+	for _, list := range []string{name, ranges} {
+		b, err := OpenBreachList(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range append(breached, "filler-0", "filler-199") {
+			found, err := b.Contains(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !found {
+				t.Errorf("not found %v in %v", p, list)
+			}
+		}
+		for _, p := range []string{"", "filler-200", "she6Do5e", "Password"} {
+			found, err := b.Contains(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found {
+				t.Errorf("unexpected found %v in %v", p, list)
+			}
+		}
+	}
+	// breached passwords are skipped
+	pg, err := NewWithOptions(WithSeed(42), WithNumber(2), WithBreachList(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"she6Do5e", "VooSh3Ah"} {
+		if p := pg.Generate(); p != expected {
+			t.Errorf("unexpected password %v", p)
+		}
+	}
+	_, err = NewWithOptions(WithBreachList("/root/bad_123"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBreachErrors(t *testing.T) {
+	dir, err := os.MkdirTemp("", "pwgen_breach_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	digits := make([]string, 10)
+	for i := range digits {
+		digits[i] = fmt.Sprint(i)
+	}
+	name, _ := writeBreachFiles(t, dir, digits)
+	pg, err := NewWithOptions(WithPattern("9"), WithBreachList(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pg.TryGenerate()
+	var e *BreachError
+	if !errors.As(err, &e) || !errors.Is(err, ErrBreached) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err = os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pg.TryGenerate()
+	if !errors.As(err, &e) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CheckResult is a strength report of a password line, it doesn't contain the password.
+type CheckResult struct {
+	Line int `json:"line"`
+	*Strength
+}
+
+// weaknessesString returns weaknesses as "kind[start:end]" items separated by spaces.
+func (r *CheckResult) weaknessesString() string {
+	items := make([]string, len(r.Weaknesses))
+	for i, w := range r.Weaknesses {
+		items[i] = fmt.Sprintf("%s[%d:%d]", w.Kind, w.Start, w.End)
+	}
+	return strings.Join(items, " ")
+}
+
+// CheckAll reads passwords from r one per line and writes their strength reports to out
+// in the configured format: text lines, JSON array, newline delimited JSON or CSV.
+// It returns a number of passwords which violate generation rules, see Check.
+func (pg *PwGen) CheckAll(r io.Reader, out io.Writer) (int, error) {
+	var (
+		violations int
+		write      func(*CheckResult) error
+		done       func() error
+	)
+	switch pg.format {
+	case FormatJSON, FormatNDJSON:
+		var w rawWriter = NewNDJSONWriter(out)
+		if pg.format == FormatJSON {
+			w = NewJSONWriter(out)
+		}
+		write = func(c *CheckResult) error {
+			data, err := marshalJSON(c)
+			if err != nil {
+				return err
+			}
+			return w.writeRaw(data)
+		}
+		done = w.Close
+	case FormatCSV:
+		w := csv.NewWriter(out)
+		header := []string{"line", "length", "lowers", "uppers", "digits", "symbols", "entropy", "score", "weaknesses", "violations"}
+		if err := w.Write(header); err != nil {
+			return 0, err
+		}
+		write = func(c *CheckResult) error {
+			return w.Write([]string{
+				strconv.Itoa(c.Line), strconv.Itoa(c.Length), strconv.Itoa(c.Lowers), strconv.Itoa(c.Uppers),
+				strconv.Itoa(c.Digits), strconv.Itoa(c.Symbols), strconv.FormatFloat(c.Entropy, 'f', 2, 64),
+				strconv.Itoa(c.Score), c.weaknessesString(), strings.Join(c.Violations, "; "),
+			})
+		}
+		done = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	default:
+		write = func(c *CheckResult) error {
+			line := fmt.Sprintf("%d: score %d, %.2f bits", c.Line, c.Score, c.Entropy)
+			if len(c.Weaknesses) > 0 {
+				line += ", weaknesses: " + c.weaknessesString()
+			}
+			if len(c.Violations) > 0 {
+				line += ", violations: " + strings.Join(c.Violations, "; ")
+			}
+			_, err := fmt.Fprintln(out, line)
+			return err
+		}
+		done = func() error { return nil }
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		c := &CheckResult{Line: n, Strength: pg.Check(strings.TrimRight(scanner.Text(), "\r"))}
+		if len(c.Violations) > 0 {
+			violations++
+		}
+		if err := write(c); err != nil {
+			return violations, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return violations, err
+	}
+	return violations, done()
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// Errors of the configuration validation, they are wrapped by ConfigError.
+var (
+	ErrLength   = errors.New("password length should be greater than 0")
+	ErrNumber   = errors.New("passwords number should be greater than 0")
+	ErrAlphabet = errors.New("no symbols for passwords generation")
+	ErrSource   = errors.New("custom random source can not be used with secure, sha1, site or seed modes")
+	ErrWords    = errors.New("number of words should not be negative")
+	ErrWordList = errors.New("empty word list")
+	ErrPattern  = errors.New("invalid pattern")
+	ErrMode     = errors.New("passphrases and patterns can not be used together")
+	ErrPolicy   = errors.New("impossible password policy")
+	ErrSite     = errors.New("site-specific passwords require a master secret and a positive counter")
+	ErrFormat   = errors.New("unknown output format")
+	ErrWidth    = errors.New("screen width should not be negative")
+	ErrDerive   = errors.New("only one of sha1, site, seed modes and custom reader can be used")
+	ErrHash     = errors.New("invalid hash scheme")
+	ErrWorkers  = errors.New("number of workers should not be negative")
+)
+
+// ConfigError is an error of an invalid configuration field.
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+// Error returns a text of the configuration error.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Field, e.Err)
+}
+
+// Unwrap returns an original error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Config is a set of passwords generation parameters.
+type Config struct {
+	Length       int         // password length
+	Number       int         // number of generated passwords
+	RemoveChars  string      // chars which should not be used in passwords
+	SHA1File     string      // "file#seed" to derive deterministic passwords from SHA-1 hash of the file and the seed
+	NoNumerals   bool        // don't include numbers
+	Numerals     bool        // include at least one number
+	OneLine      bool        // print passwords as one line
+	NoCapitalize bool        // don't include capital letters
+	Ambiguous    bool        // don't use characters that could be confused
+	Symbols      bool        // include at least one special character
+	NoVowels     bool        // don't include vowels and numbers that might be mistaken for vowels
+	Secure       bool        // generate completely random passwords using crypto/rand
+	Source       rand.Source // custom source of pseudo-random values
+	Words        int         // number of words in passphrases, passphrases are generated if it's positive
+	WordList     string      // embedded word list name or a path to custom words file
+	Separator    string      // words separator of passphrases
+	Pattern      string      // passwords pattern, see GeneratePattern for its syntax
+	Policy       *Policy     // constraints of random passwords, it disables the phoneme-based generator
+	Master       []byte      // master secret of site-specific passwords
+	Site         string      // site name, deterministic site-specific passwords are generated if it's not empty
+	Login        string      // user name of site-specific passwords
+	Counter      int         // version of site-specific passwords, it starts from 1
+	Seed         int64       // seed of reproducible passwords, they are random if it's 0
+	Reader       io.Reader   // custom source of random bytes, ie: HSM-backed reader
+	Format       string      // output format: text (default), json, ndjson or csv
+	Metadata     bool        // include length, alphabet size and entropy to structured output
+	Width        int         // screen width of text output, it's detected if 0
+	OnePerLine   bool        // print passwords one per line, it has priority over OneLine and Columns
+	Columns      bool        // print passwords by columns even if the output is not a terminal
+	Hash         string      // hash scheme of printed passwords: bcrypt, sha512-crypt, argon2id, apr1 or sha1
+	BreachList   string      // Pwned Passwords SHA-1 sorted file or range files directory to reject breached passwords
+	NoOffensive  bool        // reject passwords with words of the embedded offensive words list
+	Blocklist    string      // file of words which are rejected in passwords, one word per line
+	Workers      int         // number of parallel generators of Print and PasswordsContext, 0 and 1 are sequential
+	Unordered    bool        // output passwords of parallel generators as soon as they're ready
+}
+
+// DefaultConfig returns a configuration with default values.
+func DefaultConfig() *Config {
+	return &Config{
+		Length:    defaultPwLength,
+		Number:    defaultNumPw,
+		Numerals:  true,
+		WordList:  WordListLarge,
+		Separator: defaultSeparator,
+		Counter:   1,
+	}
+}
+
+// Validate checks the configuration values.
+func (c *Config) Validate() error {
+	if c.Length < 1 {
+		return &ConfigError{Field: "Length", Err: ErrLength}
+	}
+	if c.Number < 1 {
+		return &ConfigError{Field: "Number", Err: ErrNumber}
+	}
+	if c.Words < 0 {
+		return &ConfigError{Field: "Words", Err: ErrWords}
+	}
+	if c.Words > 0 && c.Pattern != "" {
+		return &ConfigError{Field: "Pattern", Err: ErrMode}
+	}
+	if c.Site != "" && (len(c.Master) == 0 || c.Counter < 1) {
+		return &ConfigError{Field: "Site", Err: ErrSite}
+	}
+	if c.Site != "" && c.SHA1File != "" {
+		return &ConfigError{Field: "Site", Err: ErrDerive}
+	}
+	if c.Seed != 0 && (c.SHA1File != "" || c.Site != "") {
+		return &ConfigError{Field: "Seed", Err: ErrDerive}
+	}
+	if c.Reader != nil && (c.SHA1File != "" || c.Site != "" || c.Seed != 0) {
+		return &ConfigError{Field: "Reader", Err: ErrDerive}
+	}
+	if c.Width < 0 {
+		return &ConfigError{Field: "Width", Err: ErrWidth}
+	}
+	if c.Workers < 0 {
+		return &ConfigError{Field: "Workers", Err: ErrWorkers}
+	}
+	if !validFormat(c.Format) {
+		return &ConfigError{Field: "Format", Err: ErrFormat}
+	}
+	if !validHash(c.Hash) {
+		return &ConfigError{Field: "Hash", Err: ErrHash}
+	}
+	if c.Source != nil && (c.Secure || c.SHA1File != "" || c.Site != "" || c.Seed != 0 || c.Reader != nil) {
+		return &ConfigError{Field: "Source", Err: ErrSource}
+	}
+	return nil
+}
+
+// Option is a function to change the configuration.
+type Option func(*Config)
+
+// WithLength sets password length.
+func WithLength(n int) Option {
+	return func(c *Config) { c.Length = n }
+}
+
+// WithNumber sets number of generated passwords.
+func WithNumber(n int) Option {
+	return func(c *Config) { c.Number = n }
+}
+
+// WithRemoveChars sets chars which should not be used in passwords.
+func WithRemoveChars(chars string) Option {
+	return func(c *Config) { c.RemoveChars = chars }
+}
+
+// WithSHA1File sets a file and an optional seed after the last '#' ("file#seed")
+// to derive deterministic passwords from SHA-1 hash of the file and the seed.
+// The whole value is used as a file name without a seed if such file exists.
+// The same value and options produce the same passwords, even in secure mode.
+func WithSHA1File(name string) Option {
+	return func(c *Config) { c.SHA1File = name }
+}
+
+// WithNoNumerals excludes numbers from passwords.
+func WithNoNumerals(value bool) Option {
+	return func(c *Config) { c.NoNumerals = value }
+}
+
+// WithNumerals includes at least one number to every password.
+func WithNumerals(value bool) Option {
+	return func(c *Config) { c.Numerals = value }
+}
+
+// WithOneLine prints passwords as one line.
+func WithOneLine(value bool) Option {
+	return func(c *Config) { c.OneLine = value }
+}
+
+// WithNoCapitalize excludes capital letters from passwords.
+func WithNoCapitalize(value bool) Option {
+	return func(c *Config) { c.NoCapitalize = value }
+}
+
+// WithAmbiguous excludes characters that could be confused by the user.
+func WithAmbiguous(value bool) Option {
+	return func(c *Config) { c.Ambiguous = value }
+}
+
+// WithSymbols includes at least one special character to every password.
+func WithSymbols(value bool) Option {
+	return func(c *Config) { c.Symbols = value }
+}
+
+// WithNoVowels excludes vowels and numbers that might be mistaken for vowels.
+func WithNoVowels(value bool) Option {
+	return func(c *Config) { c.NoVowels = value }
+}
+
+// WithSecure enables completely random passwords generation using crypto/rand.
+func WithSecure(value bool) Option {
+	return func(c *Config) { c.Secure = value }
+}
+
+// WithWords enables passphrases generation with the number of words.
+func WithWords(n int) Option {
+	return func(c *Config) { c.Words = n }
+}
+
+// WithWordList sets an embedded word list name or a path to custom words file for passphrases.
+func WithWordList(name string) Option {
+	return func(c *Config) { c.WordList = name }
+}
+
+// WithSeparator sets words separator of passphrases.
+func WithSeparator(sep string) Option {
+	return func(c *Config) { c.Separator = sep }
+}
+
+// WithPattern sets passwords pattern, see GeneratePattern for its syntax.
+func WithPattern(pattern string) Option {
+	return func(c *Config) { c.Pattern = pattern }
+}
+
+// WithPolicy sets constraints of random passwords.
+func WithPolicy(policy Policy) Option {
+	return func(c *Config) { c.Policy = &policy }
+}
+
+// WithRandSource sets a custom source of pseudo-random values.
+func WithRandSource(source rand.Source) Option {
+	return func(c *Config) { c.Source = source }
+}
+
+// WithSite enables deterministic site-specific passwords derived from the master secret,
+// site name, user name and counter, see SiteKey.
+func WithSite(master []byte, site, login string, counter int) Option {
+	return func(c *Config) {
+		c.Master, c.Site, c.Login, c.Counter = master, site, login, counter
+	}
+}
+
+// WithSeed sets a seed of reproducible passwords.
+// They are generated by the built-in PCG generator, so its stream doesn't depend on Go releases,
+// the same seed and options produce the same passwords, even in secure mode.
+func WithSeed(seed int64) Option {
+	return func(c *Config) { c.Seed = seed }
+}
+
+// WithReader sets a custom source of random bytes, it's used instead of crypto/rand in secure mode too.
+// Passwords chars are chosen by the unbiased rejection sampling of 32-bit little-endian values.
+// Reader errors are returned by Iterator, PrintContext and GeneratePattern,
+// ErrRejected is returned if the reader gives only rejected values, e.g. a constant stream.
+func WithReader(r io.Reader) Option {
+	return func(c *Config) { c.Reader = r }
+}
+
+// WithFormat sets output format of Print: FormatText, FormatJSON, FormatNDJSON or FormatCSV.
+func WithFormat(format string) Option {
+	return func(c *Config) { c.Format = format }
+}
+
+// WithMetadata includes length, alphabet size and entropy to structured output.
+func WithMetadata(value bool) Option {
+	return func(c *Config) { c.Metadata = value }
+}
+
+// WithWidth sets screen width of text output by columns.
+// By default it's detected for terminals, other files get one password per line
+// and other writers use 80 columns.
+func WithWidth(n int) Option {
+	return func(c *Config) { c.Width = n }
+}
+
+// WithOnePerLine prints passwords one per line like pwgen's -1 option.
+func WithOnePerLine(value bool) Option {
+	return func(c *Config) { c.OnePerLine = value }
+}
+
+// WithColumns prints passwords by columns even if the output is not a terminal like pwgen's -C option.
+func WithColumns(value bool) Option {
+	return func(c *Config) { c.Columns = value }
+}
+
+// WithHash prints every password with its hash by the scheme:
+// HashBcrypt, HashSHA512, HashArgon2id, HashAPR1 or HashSHA1.
+func WithHash(scheme string) Option {
+	return func(c *Config) { c.Hash = scheme }
+}
+
+// WithBreachList rejects and generates again passwords which SHA-1 hashes are found
+// in the local Pwned Passwords file or range files directory, see BreachList.
+// Deterministic modes give other passwords if some of them are rejected.
+func WithBreachList(name string) Option {
+	return func(c *Config) { c.BreachList = name }
+}
+
+// WithNoOffensive rejects and generates again passwords which contain words
+// of the embedded offensive words list, it's an alternative of WithNoVowels
+// which keeps the full alphabet.
+func WithNoOffensive(value bool) Option {
+	return func(c *Config) { c.NoOffensive = value }
+}
+
+// WithBlocklist rejects and generates again passwords which contain words of the file,
+// they are matched case-insensitively after l33t normalization.
+func WithBlocklist(name string) Option {
+	return func(c *Config) { c.Blocklist = name }
+}
+
+// WithWorkers sets a number of goroutines which generate passwords for Print, WriteContext
+// and PasswordsContext, every one uses its own fork of the generator, see Fork.
+// Passwords are output in order of workers turns, so deterministic modes give the same
+// passwords for the same number of workers, but they differ from sequential ones.
+func WithWorkers(n int) Option {
+	return func(c *Config) { c.Workers = n }
+}
+
+// WithUnordered outputs passwords of parallel workers as soon as they're generated,
+// it's faster if passwords generation time varies, ie: breached passwords are rejected.
+func WithUnordered(value bool) Option {
+	return func(c *Config) { c.Unordered = value }
+}
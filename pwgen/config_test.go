@@ -0,0 +1,118 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	pg, err := NewWithOptions(
+		WithLength(12),
+		WithNumber(5),
+		WithRemoveChars("abc"),
+		WithNoNumerals(true),
+		WithNumerals(false),
+		WithOneLine(true),
+		WithNoCapitalize(true),
+		WithAmbiguous(true),
+		WithSymbols(true),
+		WithNoVowels(true),
+		WithSecure(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pg.pwLength != 12 || pg.numPw != 5 {
+		t.Errorf("unexpected length=%v or number=%v", pg.pwLength, pg.numPw)
+	}
+	if !(pg.noNumerals && !pg.numerals && pg.oneLine && pg.noCapitalize && pg.ambiguous && pg.symbols && pg.secure) {
+		t.Errorf("unexpected flags: %#v", pg)
+	}
+	if pg.phoneme {
+		t.Error("unexpected phonemes usage")
+	}
+	removed := []byte("abc" + pwDigits + pwVowels + pwAmbiguous + pwUppers)
+	for _, c := range pg.chars {
+		for _, r := range removed {
+			if c == r {
+				t.Errorf("unexpected char %q in alphabet", c)
+			}
+		}
+	}
+}
+
+func TestNewWithOptionsDefault(t *testing.T) {
+	pg, err := NewWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pg.pwLength != defaultPwLength || pg.numPw != defaultNumPw {
+		t.Errorf("unexpected length=%v or number=%v", pg.pwLength, pg.numPw)
+	}
+	if !pg.numerals || !pg.phoneme {
+		t.Errorf("unexpected default flags: %#v", pg)
+	}
+}
+
+func TestWithRandSource(t *testing.T) {
+	var s1, s2 []string
+	for _, s := range []*[]string{&s1, &s2} {
+		pg, err := NewWithOptions(WithLength(16), WithNumber(100), WithRandSource(rand.NewSource(42)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for p := range pg.Passwords() {
+			*s = append(*s, p)
+		}
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			t.Errorf("not equal %v != %v", s1[i], s2[i])
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	values := []struct {
+		opts  []Option
+		field string
+		err   error
+	}{
+		{[]Option{WithLength(0)}, "Length", ErrLength},
+		{[]Option{WithNumber(-1)}, "Number", ErrNumber},
+		{[]Option{WithRemoveChars(pwLowers + pwDigits + pwUppers)}, "RemoveChars", ErrAlphabet},
+		{[]Option{WithSecure(true), WithRandSource(rand.NewSource(1))}, "Source", ErrSource},
+		{[]Option{WithSHA1File("/root/bad_123")}, "SHA1File", os.ErrNotExist},
+		{[]Option{WithSite(nil, "example.com", "admin", 1)}, "Site", ErrSite},
+		{[]Option{WithSite([]byte("secret"), "example.com", "admin", 0)}, "Site", ErrSite},
+		{[]Option{WithSite([]byte("secret"), "example.com", "", 1), WithSHA1File("/root/bad_123")}, "Site", ErrDerive},
+		{[]Option{WithSite([]byte("secret"), "example.com", "", 1), WithRandSource(rand.NewSource(1))}, "Source", ErrSource},
+		{[]Option{WithReader(bytes.NewReader(nil)), WithSeed(1)}, "Reader", ErrDerive},
+		{[]Option{WithReader(bytes.NewReader(nil)), WithRandSource(rand.NewSource(1))}, "Source", ErrSource},
+	}
+	for i, v := range values {
+		_, err := NewWithOptions(v.opts...)
+		if err == nil {
+			t.Errorf("[%v] no expected error", i)
+			continue
+		}
+		var e *ConfigError
+		if !errors.As(err, &e) {
+			t.Errorf("[%v] unexpected error type: %T", i, err)
+			continue
+		}
+		if e.Field != v.field {
+			t.Errorf("[%v] unexpected field %v", i, e.Field)
+		}
+		if !errors.Is(err, v.err) {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// drbgVersion is a tag of the deterministic random bits generator algorithm,
+	// it has to be changed with any change of the derivation.
+	drbgVersion = "gopwgen-drbg-v1"
+	// sha1KeyVersion is a tag of the file and seed key derivation.
+	sha1KeyVersion = "gopwgen-sha1-v1"
+	// siteKeyVersion is a tag of the site-specific key derivation.
+	siteKeyVersion = "gopwgen-site-v1"
+
+	// Argon2id parameters of site-specific keys, they are a part of the derivation version.
+	siteArgonTime    = 3
+	siteArgonMemory  = 64 * 1024 // KiB
+	siteArgonThreads = 4
+	siteKeyLength    = 32
+)
+
+// drbg is a deterministic random bits generator.
+// Its output is a sequence of blocks HMAC-SHA256(key, "gopwgen-drbg-v1" || counter),
+// where counter is a 64-bit big-endian number starting from 0.
+// Passwords chars are chosen from its output by the rejection sampling of uniform,
+// so the same key produces the same passwords regardless math/rand implementation.
+type drbg struct {
+	mac     hash.Hash
+	counter uint64
+	block   []byte
+	pos     int
+}
+
+// newDRBG returns a new deterministic random bits generator for the key.
+func newDRBG(key []byte) *drbg {
+	return &drbg{mac: hmac.New(sha256.New, key)}
+}
+
+// Read fills p by next bytes of the generator, it never fails.
+func (d *drbg) Read(p []byte) (int, error) {
+	var counter [8]byte
+	n := 0
+	for n < len(p) {
+		if d.pos == len(d.block) {
+			binary.BigEndian.PutUint64(counter[:], d.counter)
+			d.mac.Reset()
+			d.mac.Write([]byte(drbgVersion))
+			d.mac.Write(counter[:])
+			d.block = d.mac.Sum(d.block[:0])
+			d.counter++
+			d.pos = 0
+		}
+		m := copy(p[n:], d.block[d.pos:])
+		d.pos += m
+		n += m
+	}
+	return n, nil
+}
+
+// split returns a new generator which key is the next output block of d.
+func (d *drbg) split() io.Reader {
+	key := make([]byte, sha256.Size)
+	_, _ = d.Read(key) // it never fails
+	child := newDRBG(key)
+	wipe(key)
+	return child
+}
+
+// splitSHA1File returns a file name and an optional seed from "file#seed" value,
+// the seed follows the last '#'. The whole value is a file name if such file exists,
+// so names with '#' can be used without a seed.
+func splitSHA1File(value string) (string, string) {
+	if _, err := os.Stat(value); err == nil {
+		return value, ""
+	}
+	i := strings.LastIndexByte(value, '#')
+	if i < 0 {
+		return value, ""
+	}
+	return value[:i], value[i+1:]
+}
+
+// sha1Key returns a key of the deterministic generator for "file#seed" value.
+// It's HMAC-SHA256(SHA-1(file content), "gopwgen-sha1-v1" || 0x00 || seed).
+func sha1Key(value string) ([]byte, error) {
+	name, seed := splitSHA1File(value)
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return nil, err
+	}
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, h.Sum(nil))
+	mac.Write([]byte(sha1KeyVersion))
+	mac.Write([]byte{0})
+	mac.Write([]byte(seed))
+	return mac.Sum(nil), nil
+}
+
+// SiteKey returns a key of the deterministic generator for site-specific passwords.
+// It's Argon2id(master, salt) with 3 passes, 64 MiB of memory and 4 lanes, where
+// salt = SHA-256("gopwgen-site-v1" || len(site) || site || len(login) || login || counter),
+// lengths and the counter are 32-bit big-endian numbers.
+// The same master secret, site, login, counter and generator options produce the same passwords.
+func SiteKey(master []byte, site, login string, counter int) []byte {
+	var n [4]byte
+	h := sha256.New()
+	h.Write([]byte(siteKeyVersion))
+	for _, value := range []string{site, login} {
+		binary.BigEndian.PutUint32(n[:], uint32(len(value)))
+		h.Write(n[:])
+		h.Write([]byte(value))
+	}
+	binary.BigEndian.PutUint32(n[:], uint32(counter))
+	h.Write(n[:])
+	return argon2.IDKey(master, h.Sum(nil), siteArgonTime, siteArgonMemory, siteArgonThreads, siteKeyLength)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestDRBG(t *testing.T) {
+	key := []byte("secret key")
+	d := newDRBG(key)
+	data := make([]byte, 100)
+	// reads cross blocks borders
+	for i := 0; i < len(data); i += 7 {
+		j := i + 7
+		if j > len(data) {
+			j = len(data)
+		}
+		if n, err := d.Read(data[i:j]); err != nil || n != j-i {
+			t.Fatalf("failed read n=%v: %v", n, err)
+		}
+	}
+	var expected []byte
+	for i := byte(0); i < 4; i++ {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("gopwgen-drbg-v1"))
+		mac.Write([]byte{0, 0, 0, 0, 0, 0, 0, i})
+		expected = mac.Sum(expected)
+	}
+	if !bytes.Equal(data, expected[:len(data)]) {
+		t.Errorf("unexpected output %x", data)
+	}
+}
+
+func TestSplitSHA1File(t *testing.T) {
+	values := []struct {
+		value, name, seed string
+	}{
+		{"file.mp3", "file.mp3", ""},
+		{"file.mp3#user@example.com", "file.mp3", "user@example.com"},
+		{"/tmp/a#b/file.mp3#seed", "/tmp/a#b/file.mp3", "seed"},
+		{"file.mp3#", "file.mp3", ""},
+	}
+	for i, v := range values {
+		name, seed := splitSHA1File(v.value)
+		if name != v.name || seed != v.seed {
+			t.Errorf("[%v] unexpected result %q, %q", i, name, seed)
+		}
+	}
+	// an existing file with '#' in its name
+	dir, err := os.MkdirTemp("", "pwgen_sha1_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	fullName := path.Join(dir, "song#1.mp3")
+	if err = os.WriteFile(fullName, []byte("abcdef"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if name, seed := splitSHA1File(fullName); name != fullName || seed != "" {
+		t.Errorf("unexpected result %q, %q", name, seed)
+	}
+	if name, seed := splitSHA1File(fullName + "#seed"); name != fullName || seed != "seed" {
+		t.Errorf("unexpected seed result %q, %q", name, seed)
+	}
+}
+
+func TestSHA1Seed(t *testing.T) {
+	fullName := path.Join(os.TempDir(), "pwgen_sha1_test.tmp")
+	err := os.WriteFile(fullName, []byte("abcdef"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(fullName); err != nil {
+			t.Error(err)
+		}
+	}()
+	generate := func(value string, opts ...Option) string {
+		pg, err := NewWithOptions(append(opts, WithSHA1File(value), WithNumber(4), WithOneLine(true))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err = pg.Print(&b); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	// known answers, they must not be changed between versions
+	values := []struct {
+		value    string
+		opts     []Option
+		expected string
+	}{
+		{fullName, nil, "iQuai6Ao eiXoish3 sia8Zei2 azei4Cea \n"},
+		{fullName + "#user@example.com", nil, "eghaiHe9 Que0ie8v OoCh8nuu Eipaesh3 \n"},
+		{fullName + "#user@example.com", []Option{WithSecure(true), WithSymbols(true), WithLength(12)}, "]d2-2}mox)en Uc8HU4%8LIl9 ^c-}2?F9fOdG X^|r\\0z}ks7c \n"},
+	}
+	for i, v := range values {
+		if s := generate(v.value, v.opts...); s != v.expected {
+			t.Errorf("[%v] unexpected passwords %q", i, s)
+		}
+	}
+	if s := generate(fullName + "#other@example.com"); s == values[1].expected {
+		t.Error("seed is ignored")
+	}
+	_, err = NewWithOptions(WithSHA1File("/root/bad_123#seed"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSiteKey(t *testing.T) {
+	master := []byte("correct horse battery staple")
+	key := SiteKey(master, "example.com", "admin", 1)
+	// known answer, it must not be changed between versions
+	if s := hex.EncodeToString(key); s != "63871d2ec1274a65eb2bf47fab4477520357cdfb05469fe503494d818a4cfb01" {
+		t.Errorf("unexpected key %v", s)
+	}
+	values := [][]byte{
+		SiteKey([]byte("other"), "example.com", "admin", 1),
+		SiteKey(master, "example.org", "admin", 1),
+		SiteKey(master, "example.com", "root", 1),
+		SiteKey(master, "example.com", "admin", 2),
+		// length prefixes split site and login
+		SiteKey(master, "example.coma", "dmin", 1),
+	}
+	for i, v := range values {
+		if bytes.Equal(v, key) {
+			t.Errorf("[%v] equal keys", i)
+		}
+	}
+}
+
+func TestSitePasswords(t *testing.T) {
+	master := []byte("correct horse battery staple")
+	generate := func(opts ...Option) string {
+		pg, err := NewWithOptions(append(opts, WithNumber(2), WithOneLine(true))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err = pg.Print(&b); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	// known answers, they must not be changed between versions
+	values := []struct {
+		opts     []Option
+		expected string
+	}{
+		{[]Option{WithSite(master, "example.com", "admin", 1), WithSecure(true), WithLength(16)}, "XnIusPxl30zRkncW 3uy6uCu6D6btWnTp \n"},
+		{[]Option{WithSite(master, "example.com", "admin", 2), WithSecure(true), WithSymbols(true)}, "Z0P*}WMw qzH2hL/D \n"},
+		{[]Option{WithSite(master, "example.com", "admin", 1), WithWords(4)}, "Joyride0-Playtime-Handrail-Pond Tartly-Undress0-Urgent-Repulsive \n"},
+	}
+	for i, v := range values {
+		if s := generate(v.opts...); s != v.expected {
+			t.Errorf("[%v] unexpected passwords %q", i, s)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"math"
+	"strings"
+)
+
+// binaryEntropy returns bits of entropy of a random event with probability p.
+func binaryEntropy(p float64) float64 {
+	return -p*math.Log2(p) - (1-p)*math.Log2(1-p)
+}
+
+// countsEntropy returns bits of entropy of a uniform choice of items with repeats counts.
+func countsEntropy(counts []int) float64 {
+	var result float64
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(total)
+			result -= p * math.Log2(p)
+		}
+	}
+	return result
+}
+
+// charsEntropy returns bits of entropy of a uniform choice from chars
+// skipping excluded ones, chars can contain duplicates.
+func charsEntropy(chars []byte, excluded string) float64 {
+	var counts [256]int
+	for _, c := range chars {
+		if strings.IndexByte(excluded, c) < 0 {
+			counts[c]++
+		}
+	}
+	return countsEntropy(counts[:])
+}
+
+// slotEntropy returns lower bound bits of entropy of a char from chars,
+// if repeats are limited, any one of chars can be forbidden.
+func slotEntropy(chars []byte, excluded string, limitRepeats bool) float64 {
+	result := charsEntropy(chars, excluded)
+	if !limitRepeats {
+		return result
+	}
+	for _, c := range chars {
+		if strings.IndexByte(excluded, c) < 0 {
+			result = math.Min(result, charsEntropy(chars, excluded+string(c)))
+		}
+	}
+	return result
+}
+
+// Entropy returns estimated bits of entropy of generated passwords.
+// It's exact for patterns, passphrases and random passwords without required classes and other policy rules.
+// Chars of required classes are counted only by their classes, their random positions are ignored,
+// and maximum limits of the policy are treated as excluded classes of other chars,
+// so it's a lower bound for other random passwords, including the secure mode with numerals or symbols.
+// The phoneme-based generator value is an upper bound,
+// because different elements sequences can produce the same password, see EntropyIsUpperBound.
+func (pg *PwGen) Entropy() float64 {
+	if pg.words > 0 {
+		return pg.passphraseEntropy()
+	}
+	if pg.pattern != nil {
+		return pg.patternEntropy()
+	}
+	if pg.usePhonemes() {
+		return pg.phonemeEntropy()
+	}
+	return pg.randomEntropy()
+}
+
+// EntropyIsUpperBound returns true if Entropy is an upper bound of bits of entropy,
+// it's so for pronounceable passwords, so the value overstates their strength.
+func (pg *PwGen) EntropyIsUpperBound() bool {
+	return pg.words == 0 && pg.pattern == nil && pg.usePhonemes()
+}
+
+// randomEntropy returns lower bound bits of entropy of random passwords.
+func (pg *PwGen) randomEntropy() float64 {
+	var result, firstLoss float64
+	min, max := pg.policy.limits()
+	limitRepeats := pg.policy.MaxConsecutive > 0
+	free := pg.pwLength
+	for k := 0; k < numClasses; k++ {
+		if min[k] == 0 {
+			continue
+		}
+		h := slotEntropy(pg.classes[k], "", limitRepeats)
+		result += float64(min[k]) * h
+		firstLoss = math.Max(firstLoss, h-slotEntropy(pg.classes[k], pg.policy.ForbiddenFirst, limitRepeats))
+		free -= min[k]
+	}
+	if free > 0 {
+		var excluded string
+		for k := 0; k < numClasses; k++ {
+			if max[k] > 0 {
+				excluded += classAlphabets[k]
+			}
+		}
+		h := slotEntropy(pg.chars, excluded, limitRepeats)
+		result += float64(free) * h
+		firstLoss = math.Max(firstLoss, h-slotEntropy(pg.chars, excluded+pg.policy.ForbiddenFirst, limitRepeats))
+	}
+	return math.Max(result-firstLoss, 0)
+}
+
+// patternEntropy returns bits of entropy of passwords by the pattern.
+func (pg *PwGen) patternEntropy() float64 {
+	var result float64
+	for _, chars := range pg.pattern {
+		result += charsEntropy(chars, "")
+	}
+	return result
+}
+
+// passphraseEntropy returns bits of entropy of passphrases.
+func (pg *PwGen) passphraseEntropy() float64 {
+	counts := make(map[string]int, len(pg.wordList))
+	for _, word := range pg.wordList {
+		counts[word]++
+	}
+	values := make([]int, 0, len(counts))
+	for _, c := range counts {
+		values = append(values, c)
+	}
+	result := float64(pg.words) * countsEntropy(values)
+	position := math.Log2(float64(pg.words))
+	if pg.numerals {
+		if digits := pg.filter(pwDigits); len(digits) > 0 {
+			result += position + charsEntropy(digits, "")
+		}
+	}
+	if pg.symbols {
+		if symbols := pg.filter(pwSymbols); len(symbols) > 0 {
+			result += position + charsEntropy(symbols, "")
+		}
+	}
+	return result
+}
+
+// phonemeState is a state of the phoneme-based generator.
+type phonemeState struct {
+	size, shouldBe   int
+	prevVowel, first bool
+}
+
+// phonemeEntropy returns upper bound bits of entropy of pronounceable passwords,
+// it follows random choices of fillPhonemes.
+func (pg *PwGen) phonemeEntropy() float64 {
+	var ambiguous string
+	if pg.ambiguous {
+		ambiguous = pwAmbiguous
+	}
+	var (
+		features = pg.phonemeFeatures()
+		digits   = charsEntropy([]byte(pwDigits), ambiguous)
+		symbols  = charsEntropy([]byte(pwSymbols), ambiguous)
+		upper    = binaryEntropy(0.2)
+		memo     = make(map[phonemeState]float64)
+		state    func(s phonemeState) float64
+		newPart  func(size int) float64
+	)
+	newPart = func(size int) float64 {
+		if size <= 0 {
+			return 0
+		}
+		return 1 + 0.5*state(phonemeState{size, phConsonant, false, true}) +
+			0.5*state(phonemeState{size, phVowel, false, true})
+	}
+	state = func(s phonemeState) float64 {
+		if s.size <= 0 {
+			return 0
+		}
+		if v, ok := memo[s]; ok {
+			return v
+		}
+		var elements []*phonemeElement
+		for i := range phonemeElements {
+			e := &phonemeElements[i]
+			switch {
+			case e.flags&s.shouldBe == 0:
+			case s.first && (e.flags&phNotFirst != 0):
+			case s.prevVowel && (e.flags&phVowel != 0) && (e.flags&phDiphthong != 0):
+			case len(e.str) > s.size:
+			default:
+				elements = append(elements, e)
+			}
+		}
+		n := float64(len(elements))
+		result := math.Log2(n)
+		for _, e := range elements {
+			var h float64
+			if (features&featureUppers != 0) && (s.first || e.flags&phConsonant != 0) {
+				h += upper
+			}
+			size := s.size - len(e.str)
+			vowel := e.flags&phVowel != 0
+			next := func(size int) float64 {
+				switch {
+				case size <= 0:
+					return 0
+				case s.shouldBe == phConsonant:
+					return state(phonemeState{size, phVowel, vowel, false})
+				case s.prevVowel || (e.flags&phDiphthong != 0):
+					return state(phonemeState{size, phConsonant, vowel, false})
+				}
+				return binaryEntropy(0.6) + 0.6*state(phonemeState{size, phConsonant, vowel, false}) +
+					0.4*state(phonemeState{size, phVowel, vowel, false})
+			}
+			symbol := func(size int) float64 {
+				if (features&featureSymbols == 0) || s.first {
+					return next(size)
+				}
+				return binaryEntropy(0.2) + 0.2*(symbols+next(size-1)) + 0.8*next(size)
+			}
+			switch {
+			case size <= 0:
+			case (features&featureDigits != 0) && !s.first:
+				h += binaryEntropy(0.3) + 0.3*(digits+newPart(size-1)) + 0.7*symbol(size)
+			default:
+				h += symbol(size)
+			}
+			result += h / n
+		}
+		memo[s] = result
+		return result
+	}
+	return newPart(pg.pwLength)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	values := []struct {
+		opts     []Option
+		expected float64
+	}{
+		{[]Option{WithSecure(true), WithNumerals(false)}, 8 * math.Log2(62)},
+		{[]Option{WithSecure(true), WithLength(10)}, math.Log2(10) + 9*math.Log2(62)},
+		{[]Option{WithRemoveChars("abc"), WithNumerals(false), WithNoCapitalize(true)}, 8 * math.Log2(33)},
+		{[]Option{WithLength(12), WithPolicy(Policy{MinUppers: 2, MinDigits: 3})}, 2*math.Log2(26) + 3*math.Log2(10) + 7*math.Log2(62)},
+		{[]Option{WithPolicy(Policy{MaxDigits: 2})}, math.Log2(10) + 7*math.Log2(52)},
+		{[]Option{WithPolicy(Policy{ForbiddenFirst: pwDigits}), WithNumerals(false)}, math.Log2(52) + 7*math.Log2(62)},
+		{[]Option{WithPolicy(Policy{MaxConsecutive: 1}), WithNumerals(false)}, 8 * math.Log2(61)},
+		{[]Option{WithPattern("Cvccvc-9{2}")}, 4*math.Log2(20) + 2*math.Log2(6) + 2*math.Log2(10)},
+		{[]Option{WithPattern("Cvccvc-9{2}"), WithAmbiguous(true)}, math.Log2(14) + 3*math.Log2(19) + 2*math.Log2(6) + 2*math.Log2(4)},
+		{[]Option{WithWords(4), WithNumerals(false)}, 4 * math.Log2(7776)},
+		{[]Option{WithWords(5), WithWordList(WordListShort)}, 5*math.Log2(1296) + math.Log2(5) + math.Log2(10)},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(v.opts...)
+		if err != nil {
+			t.Fatalf("[%v] %v", i, err)
+		}
+		if e := pg.Entropy(); math.Abs(e-v.expected) > 1e-9 {
+			t.Errorf("[%v] unexpected entropy %v, expected %v", i, e, v.expected)
+		}
+		if pg.EntropyIsUpperBound() {
+			t.Errorf("[%v] entropy is an upper bound", i)
+		}
+	}
+}
+
+func TestEntropyRequiredPosition(t *testing.T) {
+	// a digit is at a random position, so passwords with two digits are more probable
+	pg, err := NewWithOptions(WithSecure(true), WithLength(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact := 1040.0/1240*math.Log2(1240) + 200.0/1240*math.Log2(620)
+	if e := pg.Entropy(); math.Abs(e-math.Log2(620)) > 1e-9 || e >= exact {
+		t.Errorf("unexpected entropy %v, exact %v", e, exact)
+	}
+}
+
+func TestEntropyDuplicates(t *testing.T) {
+	// the only char is a required symbol, symbols have no duplicates
+	pg, err := NewWithOptions(WithSecure(true), WithSymbols(true), WithNumerals(false), WithLength(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := pg.Entropy(); math.Abs(e-math.Log2(float64(len(pwSymbols)))) > 1e-9 {
+		t.Errorf("unexpected entropy %v", e)
+	}
+	// a duplicate char is chosen twice as often
+	expected := 2.0/3*math.Log2(3.0/2) + 1.0/3*math.Log2(3)
+	if e := charsEntropy([]byte("aab"), ""); math.Abs(e-expected) > 1e-9 {
+		t.Errorf("unexpected entropy %v, expected %v", e, expected)
+	}
+}
+
+func TestEntropyPhonemes(t *testing.T) {
+	var prev float64
+	for length := minPhonemeLength; length < 20; length++ {
+		pg, err := NewWithOptions(WithLength(length))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pg.usePhonemes() || !pg.EntropyIsUpperBound() {
+			t.Fatal("phonemes are not used")
+		}
+		e := pg.Entropy()
+		if e <= prev {
+			t.Errorf("not increased entropy %v for length %v", e, length)
+		}
+		// pronounceable passwords are weaker than random ones
+		if limit := float64(length) * math.Log2(62); e >= limit {
+			t.Errorf("too big entropy %v for length %v", e, length)
+		}
+		prev = e
+	}
+	pg, err := NewWithOptions(WithSymbols(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := pg.Entropy(); e <= 0 || e >= 8*math.Log2(95) {
+		t.Errorf("unexpected entropy %v", e)
+	}
+}
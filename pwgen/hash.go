@@ -0,0 +1,272 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hash schemes.
+const (
+	HashBcrypt   = "bcrypt"       // bcrypt with the default cost, it's supported by htpasswd
+	HashSHA512   = "sha512-crypt" // SHA-512 crypt "$6$" with the default 5000 rounds
+	HashArgon2id = "argon2id"     // Argon2id PHC string with m=19456, t=2, p=1
+	HashAPR1     = "apr1"         // Apache MD5 "$apr1$" of htpasswd
+	HashSHA1     = "sha1"         // Apache "{SHA}" of htpasswd, it's not salted and insecure
+)
+
+const (
+	cryptAlphabet     = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	sha512CryptRounds = 5000 // default rounds, they are not included to hashes
+	sha512SaltLength  = 16
+	apr1SaltLength    = 8
+	apr1Rounds        = 1000
+
+	argon2idMemory    = 19 * 1024 // KiB
+	argon2idTime      = 2
+	argon2idThreads   = 1
+	argon2idKeyLength = 32
+	argon2idSalt      = 16
+
+	bcryptMaxLength = 72 // bcrypt rejects longer passwords
+)
+
+// sha512CryptOrder is an order of SHA-512 crypt bytes in the encoded hash.
+var sha512CryptOrder = [...][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+	{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+	{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41},
+}
+
+// apr1Order is an order of Apache MD5 bytes in the encoded hash.
+var apr1Order = [...][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}}
+
+// validHash returns true if the hash scheme is supported, empty one means no hashes.
+func validHash(scheme string) bool {
+	switch scheme {
+	case "", HashBcrypt, HashSHA512, HashArgon2id, HashAPR1, HashSHA1:
+		return true
+	}
+	return false
+}
+
+// HashPassword returns a hash of the password by the scheme with a random salt.
+// Salts are read from the shared CSPRNG, its failures are returned as *RandomError.
+func HashPassword(scheme string, password []byte) (string, error) {
+	switch scheme {
+	case HashBcrypt:
+		h, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return string(h), nil
+	case HashSHA512:
+		salt, err := cryptSalt(sha512SaltLength)
+		if err != nil {
+			return "", err
+		}
+		return sha512Crypt(password, salt, sha512CryptRounds), nil
+	case HashArgon2id:
+		salt := make([]byte, argon2idSalt)
+		if _, err := secureRandom.Read(salt); err != nil {
+			return "", &RandomError{Err: err}
+		}
+		return argon2idHash(password, salt), nil
+	case HashAPR1:
+		salt, err := cryptSalt(apr1SaltLength)
+		if err != nil {
+			return "", err
+		}
+		return apr1Crypt(password, salt), nil
+	case HashSHA1:
+		h := sha1.Sum(password)
+		return "{SHA}" + base64.StdEncoding.EncodeToString(h[:]), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrHash, scheme)
+}
+
+// checkHashWidth returns *ConfigError with ErrHash if passwords of the width can't be hashed by the scheme.
+func checkHashWidth(scheme string, width int) error {
+	if scheme == HashBcrypt && width > bcryptMaxLength {
+		err := fmt.Errorf("%w: bcrypt passwords are limited by %d bytes, but %d can be generated", ErrHash, bcryptMaxLength, width)
+		return &ConfigError{Field: "Hash", Err: err}
+	}
+	return nil
+}
+
+// cryptSalt returns a random salt of crypt alphabet chars.
+func cryptSalt(n int) ([]byte, error) {
+	salt := make([]byte, n)
+	for i := range salt {
+		j, err := uniform(secureRandom, len(cryptAlphabet))
+		if err != nil {
+			return nil, &RandomError{Err: err}
+		}
+		salt[i] = cryptAlphabet[j]
+	}
+	return salt, nil
+}
+
+// cryptEncode appends n chars of crypt base64 encoding of 3 bytes to the builder.
+func cryptEncode(b *strings.Builder, b2, b1, b0 byte, n int) {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		b.WriteByte(cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+}
+
+// repeatSum adds n bytes of the repeated block to the hash.
+func repeatSum(h hash.Hash, block []byte, n int) {
+	for ; n > len(block); n -= len(block) {
+		h.Write(block)
+	}
+	h.Write(block[:n])
+}
+
+// sha512Crypt returns SHA-512 crypt hash of the password by Ulrich Drepper's specification.
+func sha512Crypt(password, salt []byte, rounds int) string {
+	h := sha512.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	alt := h.Sum(nil)
+
+	h.Reset()
+	h.Write(password)
+	h.Write(salt)
+	repeatSum(h, alt, len(password))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write(alt)
+		} else {
+			h.Write(password)
+		}
+	}
+	sum := h.Sum(nil)
+
+	h.Reset()
+	for range password {
+		h.Write(password)
+	}
+	p := make([]byte, len(password))
+	for dp, i := h.Sum(nil), 0; i < len(p); i += len(dp) {
+		copy(p[i:], dp)
+	}
+
+	h.Reset()
+	for i := 0; i < 16+int(sum[0]); i++ {
+		h.Write(salt)
+	}
+	s := make([]byte, len(salt))
+	copy(s, h.Sum(nil))
+
+	for r := 0; r < rounds; r++ {
+		h.Reset()
+		if r&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(sum)
+		}
+		if r%3 != 0 {
+			h.Write(s)
+		}
+		if r%7 != 0 {
+			h.Write(p)
+		}
+		if r&1 != 0 {
+			h.Write(sum)
+		} else {
+			h.Write(p)
+		}
+		sum = h.Sum(sum[:0])
+	}
+
+	var b strings.Builder
+	b.WriteString("$6$")
+	if rounds != sha512CryptRounds {
+		fmt.Fprintf(&b, "rounds=%d$", rounds)
+	}
+	b.Write(salt)
+	b.WriteByte('$')
+	for _, i := range sha512CryptOrder {
+		cryptEncode(&b, sum[i[0]], sum[i[1]], sum[i[2]], 4)
+	}
+	cryptEncode(&b, 0, 0, sum[63], 2)
+	return b.String()
+}
+
+// apr1Crypt returns Apache MD5 hash of the password.
+func apr1Crypt(password, salt []byte) string {
+	const magic = "$apr1$"
+	h := md5.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	alt := h.Sum(nil)
+
+	h.Reset()
+	h.Write(password)
+	h.Write([]byte(magic))
+	h.Write(salt)
+	repeatSum(h, alt, len(password))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(password[:1])
+		}
+	}
+	sum := h.Sum(nil)
+
+	for r := 0; r < apr1Rounds; r++ {
+		h.Reset()
+		if r&1 != 0 {
+			h.Write(password)
+		} else {
+			h.Write(sum)
+		}
+		if r%3 != 0 {
+			h.Write(salt)
+		}
+		if r%7 != 0 {
+			h.Write(password)
+		}
+		if r&1 != 0 {
+			h.Write(sum)
+		} else {
+			h.Write(password)
+		}
+		sum = h.Sum(sum[:0])
+	}
+
+	var b strings.Builder
+	b.WriteString(magic)
+	b.Write(salt)
+	b.WriteByte('$')
+	for _, i := range apr1Order {
+		cryptEncode(&b, sum[i[0]], sum[i[1]], sum[i[2]], 4)
+	}
+	cryptEncode(&b, 0, 0, sum[11], 2)
+	return b.String()
+}
+
+// argon2idHash returns Argon2id PHC string of the password.
+func argon2idHash(password, salt []byte) string {
+	key := argon2.IDKey(password, salt, argon2idTime, argon2idMemory, argon2idThreads, argon2idKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2idMemory, argon2idTime, argon2idThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	)
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const longPassword = "a password longer than sixteen bytes and sixty four bytes .............................................."
+
+func TestSHA512Crypt(t *testing.T) {
+	values := []struct {
+		password string
+		salt     string
+		rounds   int
+		expected string
+	}{
+		{
+			password: "Hello world!", salt: "saltstring", rounds: sha512CryptRounds,
+			expected: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+		},
+		{
+			password: "Hello world!", salt: "saltstringsaltst", rounds: 10000,
+			expected: "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.",
+		},
+		{
+			password: "a", salt: "abcDEF0123456789", rounds: sha512CryptRounds,
+			expected: "$6$abcDEF0123456789$OsRc8YcrhFnMahRRNGuvnbnxMPVbnehoraVZQy5QPveMGJ60k4mlkNQpZhGNwrcWQ9Ll0JlO77AcA.5s0K46e.",
+		},
+		{
+			password: longPassword, salt: "x", rounds: sha512CryptRounds,
+			expected: "$6$x$eXb6L1ixUx0LQj0wyz81RHCtM6tcwqFqOMmYkwuvHcQejdzxaN.eNjlNmJnAwCMMmh12UZS1G2O3wczhxEChV1",
+		},
+	}
+	for i, v := range values {
+		if h := sha512Crypt([]byte(v.password), []byte(v.salt), v.rounds); h != v.expected {
+			t.Errorf("failed case=%d: %s", i, h)
+		}
+	}
+}
+
+func TestAPR1Crypt(t *testing.T) {
+	values := []struct {
+		password string
+		salt     string
+		expected string
+	}{
+		{password: "Hello world!", salt: "abcdefgh", expected: "$apr1$abcdefgh$Unf1zc.jsgCbBQDCL104q."},
+		{password: "", salt: "Zz./09ab", expected: "$apr1$Zz./09ab$z7VhqC/XaEN7/0Lud7ES30"},
+		{password: "a", salt: "Zz./09ab", expected: "$apr1$Zz./09ab$oDA95FYif3DMWS1ih5YAv0"},
+		{password: longPassword, salt: "Zz./09ab", expected: "$apr1$Zz./09ab$9gAz5hV3h7g6X8V3g41ML."},
+	}
+	for i, v := range values {
+		if h := apr1Crypt([]byte(v.password), []byte(v.salt)); h != v.expected {
+			t.Errorf("failed case=%d: %s", i, h)
+		}
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	password := []byte("Hello world!")
+	values := []struct {
+		scheme string
+		prefix string
+		size   int
+	}{
+		{scheme: HashBcrypt, prefix: "$2a$10$", size: 60},
+		{scheme: HashSHA512, prefix: "$6$", size: 3 + sha512SaltLength + 1 + 86},
+		{scheme: HashArgon2id, prefix: "$argon2id$v=19$m=19456,t=2,p=1$", size: 31 + 22 + 1 + 43},
+		{scheme: HashAPR1, prefix: "$apr1$", size: 6 + apr1SaltLength + 1 + 22},
+		{scheme: HashSHA1, prefix: "{SHA}00hq6RNueFa8QiEjhep5cJRHWAI=", size: 33},
+	}
+	for i, v := range values {
+		h, err := HashPassword(v.scheme, password)
+		if err != nil {
+			t.Fatalf("failed case=%d: %v", i, err)
+		}
+		if !strings.HasPrefix(h, v.prefix) || len(h) != v.size {
+			t.Errorf("failed case=%d: %s", i, h)
+		}
+		other, err := HashPassword(v.scheme, password)
+		if err != nil {
+			t.Fatalf("failed case=%d: %v", i, err)
+		}
+		if (other == h) != (v.scheme == HashSHA1) {
+			t.Errorf("failed case=%d: salt is not random %s", i, other)
+		}
+	}
+	if _, err := HashPassword("md5", password); !errors.Is(err, ErrHash) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestHashVerify(t *testing.T) {
+	password := []byte("Hello world!")
+	h, err := HashPassword(HashBcrypt, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(h), password); err != nil {
+		t.Errorf("failed bcrypt: %v", err)
+	}
+	h, err = HashPassword(HashSHA512, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := strings.Split(h, "$")[2]
+	if other := sha512Crypt(password, []byte(salt), sha512CryptRounds); other != h {
+		t.Errorf("failed sha512-crypt: %s", other)
+	}
+	h, err = HashPassword(HashAPR1, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt = strings.Split(h, "$")[2]
+	if other := apr1Crypt(password, []byte(salt)); other != h {
+		t.Errorf("failed apr1: %s", other)
+	}
+	h, err = HashPassword(HashArgon2id, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(h, "$")
+	if len(parts) != 6 {
+		t.Fatalf("failed argon2id: %s", h)
+	}
+	rawSalt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := argon2.IDKey(password, rawSalt, argon2idTime, argon2idMemory, argon2idThreads, argon2idKeyLength)
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		t.Errorf("failed argon2id: %s", h)
+	}
+}
+
+func TestOutputHash(t *testing.T) {
+	values := []struct {
+		opts     []Option
+		expected string
+	}{
+		{[]Option{WithFormat(FormatText)},
+			"ha9iFohd {SHA}8W1yJLtjjSMZRQ7uYu5OpMnKdj4=\nkaeTieg0 {SHA}6EUx4X3rWA/Sa+OYj9C+cxLRvk0=\n"},
+		{[]Option{WithFormat(FormatNDJSON)},
+			"{\"password\":\"ha9iFohd\",\"hash\":\"{SHA}8W1yJLtjjSMZRQ7uYu5OpMnKdj4=\"}\n{\"password\":\"kaeTieg0\",\"hash\":\"{SHA}6EUx4X3rWA/Sa+OYj9C+cxLRvk0=\"}\n"},
+		{[]Option{WithFormat(FormatCSV), WithMetadata(true)},
+			"password,length,alphabet_size,entropy,entropy_upper_bound,hash\nha9iFohd,8,62,31.12,true,{SHA}8W1yJLtjjSMZRQ7uYu5OpMnKdj4=\nkaeTieg0,8,62,31.12,true,{SHA}6EUx4X3rWA/Sa+OYj9C+cxLRvk0=\n"},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(append([]Option{WithSeed(42), WithNumber(2), WithHash(HashSHA1)}, v.opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err = pg.Print(&b); err != nil {
+			t.Fatal(err)
+		}
+		if s := b.String(); s != v.expected {
+			t.Errorf("[%v] unexpected output %q", i, s)
+		}
+	}
+	pg, err := NewWithOptions(WithSecure(true), WithNumber(3), WithHash(HashAPR1), WithFormat(FormatJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = pg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	if err = json.Unmarshal(b.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(records); n != 3 {
+		t.Fatalf("unexpected number of records %v", n)
+	}
+	for _, r := range records {
+		salt := strings.Split(r.Hash, "$")[2]
+		if h := apr1Crypt([]byte(r.Password), []byte(salt)); h != r.Hash {
+			t.Errorf("unexpected hash %+v", r)
+		}
+	}
+	_, err = NewWithOptions(WithHash("md5"))
+	if !errors.Is(err, ErrHash) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestHashWidth(t *testing.T) {
+	values := []struct {
+		opts []Option
+		ok   bool
+	}{
+		{[]Option{WithSecure(true), WithLength(bcryptMaxLength)}, true},
+		{[]Option{WithSecure(true), WithLength(bcryptMaxLength + 1)}, false},
+		{[]Option{WithWords(4)}, true},
+		{[]Option{WithWords(8), WithSeparator("--")}, false},
+		{[]Option{WithPattern("a{73}")}, false},
+	}
+	for i, v := range values {
+		_, err := NewWithOptions(append(v.opts, WithHash(HashBcrypt))...)
+		var ce *ConfigError
+		switch {
+		case v.ok && err != nil:
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		case !v.ok && (!errors.Is(err, ErrHash) || !errors.As(err, &ce) || ce.Field != "Hash"):
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		}
+		// other schemes have no limits
+		if _, err = NewWithOptions(append(v.opts, WithHash(HashSHA512))...); err != nil {
+			t.Errorf("[%v] unexpected sha512-crypt error: %v", i, err)
+		}
+	}
+	// bcrypt is the default scheme of htpasswd credentials
+	pg, err := NewWithOptions(WithLength(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pg.Credentials([]string{"alice"}); !errors.Is(err, ErrHash) {
+		t.Errorf("unexpected credentials error: %v", err)
+	}
+}
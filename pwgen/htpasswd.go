@@ -0,0 +1,335 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// htpasswdMode is a permission of new htpasswd files, it's the same as htpasswd tool creates.
+const htpasswdMode = 0644
+
+// ErrUser is an error of an invalid or duplicate user name.
+var ErrUser = errors.New("invalid user name")
+
+// Credential is a generated password of the user and its hash.
+// The password is a secret buffer, it should be wiped by DestroyCredentials after usage.
+type Credential struct {
+	User     string
+	Password *Secret
+	Hash     string
+}
+
+// DestroyCredentials wipes passwords of the credentials.
+func DestroyCredentials(credentials []Credential) {
+	for _, c := range credentials {
+		if c.Password != nil {
+			c.Password.Destroy()
+		}
+	}
+}
+
+// validUser returns true if the user name can be written to htpasswd files.
+func validUser(user string) bool {
+	if user == "" || len(user) > 255 || strings.HasPrefix(user, "#") {
+		return false
+	}
+	return !strings.ContainsAny(user, ": \t\r\n")
+}
+
+// ReadUsers returns user names from the reader, one per line.
+// Empty lines and comments starting with '#' are skipped.
+func ReadUsers(r io.Reader) ([]string, error) {
+	var users []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		user := strings.TrimSpace(scanner.Text())
+		if user == "" || strings.HasPrefix(user, "#") {
+			continue
+		}
+		if !validUser(user) {
+			return nil, fmt.Errorf("%w: %q", ErrUser, user)
+		}
+		users = append(users, user)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// htpasswdHash returns true if Apache and nginx can verify hashes of the scheme in htpasswd files.
+func htpasswdHash(scheme string) bool {
+	switch scheme {
+	case HashBcrypt, HashAPR1, HashSHA512, HashSHA1:
+		return true
+	}
+	return false
+}
+
+// Credentials generates a password for every user and hashes it by the configured scheme,
+// bcrypt is used by default. User names should be valid and unique.
+// Only htpasswd schemes are allowed: bcrypt, apr1, sha512-crypt (it's verified by crypt(3) of glibc)
+// and sha1, other ones return *ConfigError with ErrHash, it's also returned if passwords are too long for bcrypt.
+// Passwords are not converted to strings, the caller should call DestroyCredentials after usage.
+func (pg *PwGen) Credentials(users []string) ([]Credential, error) {
+	scheme := pg.hash
+	if scheme == "" {
+		scheme = HashBcrypt
+	}
+	if !htpasswdHash(scheme) {
+		return nil, &ConfigError{Field: "Hash", Err: fmt.Errorf("%w: %q is not supported by htpasswd files", ErrHash, scheme)}
+	}
+	if err := checkHashWidth(scheme, pg.width()); err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(users))
+	for _, user := range users {
+		if !validUser(user) {
+			return nil, fmt.Errorf("%w: %q", ErrUser, user)
+		}
+		if _, ok := names[user]; ok {
+			return nil, fmt.Errorf("%w: duplicate %q", ErrUser, user)
+		}
+		names[user] = struct{}{}
+	}
+	credentials := make([]Credential, len(users))
+	for i, user := range users {
+		password, err := pg.GenerateSecret()
+		if err != nil {
+			DestroyCredentials(credentials[:i])
+			return nil, err
+		}
+		credentials[i] = Credential{User: user, Password: password}
+		credentials[i].Hash, err = HashPassword(scheme, password.Bytes())
+		if err != nil {
+			DestroyCredentials(credentials[:i+1])
+			return nil, err
+		}
+	}
+	return credentials, nil
+}
+
+// WriteCredentials outputs cleartext credentials as "user:password" lines.
+// Passwords are written from their buffers without string copies, line buffers are wiped.
+func WriteCredentials(w io.Writer, credentials []Credential) error {
+	var line []byte
+	defer func() { wipe(line) }()
+	for _, c := range credentials {
+		line = append(line[:0], c.User...)
+		line = append(line, ':')
+		line = append(line, c.Password.Bytes()...)
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Htpasswd is a content of htpasswd file.
+// Comments, empty and unknown lines are kept as is.
+type Htpasswd struct {
+	lines []string
+	users map[string]int // line indexes of users, the first one is used for duplicate users
+}
+
+// htpasswdUser returns a user name of htpasswd file line.
+func htpasswdUser(line string) (string, bool) {
+	i := strings.IndexByte(line, ':')
+	if i < 1 || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+	return line[:i], true
+}
+
+// ReadHtpasswd parses htpasswd file content from the reader.
+func ReadHtpasswd(r io.Reader) (*Htpasswd, error) {
+	h := &Htpasswd{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		h.lines = append(h.lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	h.index()
+	return h, nil
+}
+
+// index sets line indexes of users. Apache and nginx check only the first line
+// of a user, so it's used if there are duplicates.
+func (h *Htpasswd) index() {
+	h.users = make(map[string]int)
+	for i, line := range h.lines {
+		user, ok := htpasswdUser(line)
+		if !ok {
+			continue
+		}
+		if _, ok = h.users[user]; !ok {
+			h.users[user] = i
+		}
+	}
+}
+
+// Hash returns a password hash of the user.
+func (h *Htpasswd) Hash(user string) (string, bool) {
+	i, ok := h.users[user]
+	if !ok {
+		return "", false
+	}
+	return h.lines[i][len(user)+1:], true
+}
+
+// Set adds the user or replaces its password hash.
+// Other lines of a duplicate user are removed, so its old passwords are not valid anymore.
+func (h *Htpasswd) Set(user, hash string) {
+	line := user + ":" + hash
+	i, ok := h.users[user]
+	if !ok {
+		h.users[user] = len(h.lines)
+		h.lines = append(h.lines, line)
+		return
+	}
+	h.lines[i] = line
+	lines, removed := h.lines[:i+1], false
+	for _, l := range h.lines[i+1:] {
+		if u, ok := htpasswdUser(l); ok && u == user {
+			removed = true
+			continue
+		}
+		lines = append(lines, l)
+	}
+	h.lines = lines
+	if removed {
+		h.index()
+	}
+}
+
+// WriteTo outputs htpasswd file content to w.
+func (h *Htpasswd) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, line := range h.lines {
+		n, err := io.WriteString(w, line+"\n")
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// UpdateHtpasswd adds the users to htpasswd file or replaces their password hashes,
+// duplicate lines of the users are removed, other lines are not changed. The file is created if it doesn't exist.
+// It's updated atomically: the new content is written to a temporary file
+// in the same directory which replaces the original one keeping its permissions, owner and group.
+// A file with several hard links is rewritten in place, so all links get the new content.
+func UpdateHtpasswd(name string, credentials []Credential) error {
+	// replace a target of the symbolic link but not the link itself
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	}
+	var info os.FileInfo
+	h := &Htpasswd{users: make(map[string]int)}
+	f, err := os.Open(name)
+	switch {
+	case err == nil:
+		info, err = f.Stat()
+		if err != nil {
+			_ = f.Close() // ignore error
+			return err
+		}
+		h, err = ReadHtpasswd(f)
+		if err != nil {
+			_ = f.Close() // ignore error
+			return err
+		}
+		if err = f.Close(); err != nil {
+			return err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	for _, c := range credentials {
+		h.Set(c.User, c.Hash)
+	}
+	if info != nil && fileLinks(info) > 1 {
+		return writeFileInPlace(name, info.Mode().Perm(), h)
+	}
+	return writeFileAtomic(name, info, h)
+}
+
+// writeFileInPlace truncates the file and writes the content to it.
+func writeFileInPlace(name string, mode os.FileMode, content io.WriterTo) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if err = writeSync(f, mode, content); err != nil {
+		_ = f.Close() // ignore error
+		return err
+	}
+	return f.Close()
+}
+
+// writeFileAtomic writes the content to a temporary file and renames it to the name.
+// The temporary file gets permissions, the owner and the group of the original file info,
+// new files are created with htpasswdMode if info is nil.
+func writeFileAtomic(name string, info os.FileInfo, content io.WriterTo) error {
+	mode := os.FileMode(htpasswdMode)
+	if info != nil {
+		mode = info.Mode().Perm()
+	}
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	if info != nil {
+		err = copyOwner(f, info)
+	}
+	if err == nil {
+		err = writeSync(f, mode, content)
+	}
+	if err != nil {
+		_ = f.Close()          // ignore error
+		_ = os.Remove(tmpName) // ignore error
+		return err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(tmpName) // ignore error
+		return err
+	}
+	if err = os.Rename(tmpName, name); err != nil {
+		_ = os.Remove(tmpName) // ignore error
+		return err
+	}
+	return nil
+}
+
+// writeSync writes the content to the file, sets its permissions and flushes it to the disk.
+func writeSync(f *os.File, mode os.FileMode, content io.WriterTo) error {
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err := content.WriteTo(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package pwgen
+
+import "os"
+
+// fileLinks returns a number of hard links of the file, they are not detected.
+func fileLinks(os.FileInfo) uint64 {
+	return 1
+}
+
+// copyOwner does nothing, files owners are not supported.
+func copyOwner(*os.File, os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestReadUsers(t *testing.T) {
+	values := []struct {
+		content  string
+		expected string
+		err      bool
+	}{
+		{content: "", expected: ""},
+		{content: "alice\nbob\n", expected: "alice,bob"},
+		{content: "# staging\n\n  alice  \r\nbob", expected: "alice,bob"},
+		{content: "alice\nbob:x\n", err: true},
+		{content: "alice smith\n", err: true},
+	}
+	for i, v := range values {
+		users, err := ReadUsers(strings.NewReader(v.content))
+		if v.err {
+			if !errors.Is(err, ErrUser) {
+				t.Errorf("failed case=%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed case=%d: %v", i, err)
+		}
+		if s := strings.Join(users, ","); s != v.expected {
+			t.Errorf("failed case=%d: %v", i, s)
+		}
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	content := "# users\nalice:{SHA}old\n\nbob:$apr1$x$y\n"
+	h, err := ReadHtpasswd(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash, ok := h.Hash("bob"); !ok || hash != "$apr1$x$y" {
+		t.Errorf("unexpected hash %v", hash)
+	}
+	if _, ok := h.Hash("# users"); ok {
+		t.Error("comment is a user")
+	}
+	h.Set("alice", "{SHA}new")
+	h.Set("carol", "{SHA}carol")
+	var b bytes.Buffer
+	if _, err = h.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# users\nalice:{SHA}new\n\nbob:$apr1$x$y\ncarol:{SHA}carol\n"
+	if s := b.String(); s != expected {
+		t.Errorf("unexpected content %q", s)
+	}
+}
+
+func TestHtpasswdDuplicates(t *testing.T) {
+	content := "alice:{SHA}first\nbob:{SHA}bob\nalice:{SHA}second\n# alice:x\nalice:{SHA}third\n"
+	h, err := ReadHtpasswd(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// servers check the first line of the user
+	if hash, ok := h.Hash("alice"); !ok || hash != "{SHA}first" {
+		t.Errorf("unexpected hash %v", hash)
+	}
+	h.Set("alice", "{SHA}new")
+	if hash, ok := h.Hash("alice"); !ok || hash != "{SHA}new" {
+		t.Errorf("unexpected new hash %v", hash)
+	}
+	h.Set("bob", "{SHA}newbob")
+	var b bytes.Buffer
+	if _, err = h.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "alice:{SHA}new\nbob:{SHA}newbob\n# alice:x\n"
+	if s := b.String(); s != expected {
+		t.Errorf("unexpected content %q", s)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	pg, err := NewWithOptions(WithSeed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials, err := pg.Credentials([]string{"alice", "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(credentials); n != 2 {
+		t.Fatalf("unexpected number %v", n)
+	}
+	for i, user := range []string{"alice", "bob"} {
+		c := credentials[i]
+		if c.User != user || c.Password.Len() != defaultPwLength {
+			t.Errorf("unexpected credential %+v", c)
+		}
+		if err = bcrypt.CompareHashAndPassword([]byte(c.Hash), c.Password.Bytes()); err != nil {
+			t.Errorf("unexpected hash %+v: %v", c, err)
+		}
+	}
+	var b bytes.Buffer
+	if err = WriteCredentials(&b, credentials); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "alice:ha9iFohd\nbob:kaeTieg0\n" {
+		t.Errorf("unexpected output %q", s)
+	}
+	buffers := [][]byte{credentials[0].Password.Bytes(), credentials[1].Password.Bytes()}
+	DestroyCredentials(credentials)
+	for i, buf := range buffers {
+		if !bytes.Equal(buf, make([]byte, len(buf))) {
+			t.Errorf("[%v] password is not wiped", i)
+		}
+		if n := credentials[i].Password.Len(); n != 0 {
+			t.Errorf("[%v] unexpected length %v", i, n)
+		}
+	}
+	for _, users := range [][]string{{"alice", "alice"}, {"a:b"}, {""}} {
+		if _, err = pg.Credentials(users); !errors.Is(err, ErrUser) {
+			t.Errorf("unexpected error for %v: %v", users, err)
+		}
+	}
+	pg, err = NewWithOptions(WithHash(HashArgon2id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ce *ConfigError
+	if _, err = pg.Credentials([]string{"alice"}); !errors.Is(err, ErrHash) || !errors.As(err, &ce) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateHtpasswd(t *testing.T) {
+	dir, err := os.MkdirTemp("", "pwgen_htpasswd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	name := path.Join(dir, "htpasswd")
+	credentials := []Credential{{User: "alice", Hash: "{SHA}alice"}, {User: "bob", Hash: "{SHA}bob"}}
+	if err = UpdateHtpasswd(name, credentials); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&^htpasswdMode != 0 {
+		t.Errorf("unexpected mode %v", info.Mode())
+	}
+	if err = os.Chmod(name, 0640); err != nil {
+		t.Fatal(err)
+	}
+	// update by a symbolic link
+	link := path.Join(dir, "link")
+	if err = os.Symlink(name, link); err != nil {
+		t.Fatal(err)
+	}
+	if err = UpdateHtpasswd(link, []Credential{{User: "bob", Hash: "{SHA}new"}, {User: "carol", Hash: "{SHA}c"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != "alice:{SHA}alice\nbob:{SHA}new\ncarol:{SHA}c\n" {
+		t.Errorf("unexpected content %q", s)
+	}
+	info, err = os.Lstat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := info.Mode(); m != 0640 {
+		t.Errorf("unexpected mode %v", m)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(entries); n != 2 {
+		t.Errorf("unexpected files %v", entries)
+	}
+	if err = UpdateHtpasswd("/root/bad_123/htpasswd", credentials); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package pwgen
+
+import (
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestUpdateHtpasswdOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("owner can be changed only by root")
+	}
+	dir, err := os.MkdirTemp("", "pwgen_htpasswd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	name := path.Join(dir, "htpasswd")
+	if err = os.WriteFile(name, []byte("alice:{SHA}old\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	// nobody:nogroup
+	const uid, gid = 65534, 65534
+	if err = os.Chown(name, uid, gid); err != nil {
+		t.Fatal(err)
+	}
+	if err = UpdateHtpasswd(name, []Credential{{User: "alice", Hash: "{SHA}new"}}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	if st.Uid != uid || st.Gid != gid || info.Mode().Perm() != 0640 {
+		t.Errorf("unexpected owner %v:%v or mode %v", st.Uid, st.Gid, info.Mode())
+	}
+}
+
+func TestUpdateHtpasswdHardLink(t *testing.T) {
+	dir, err := os.MkdirTemp("", "pwgen_htpasswd_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+	name, link := path.Join(dir, "htpasswd"), path.Join(dir, "link")
+	if err = os.WriteFile(name, []byte("alice:{SHA}old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Link(name, link); err != nil {
+		t.Fatal(err)
+	}
+	if err = UpdateHtpasswd(name, []Credential{{User: "alice", Hash: "{SHA}new"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, fileName := range []string{name, link} {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(data); s != "alice:{SHA}new\n" {
+			t.Errorf("unexpected content of %v: %q", fileName, s)
+		}
+	}
+}
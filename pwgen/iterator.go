@@ -0,0 +1,67 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import "context"
+
+// Iterator generates needed number of passwords one by one without any goroutines,
+// so it can be stopped at any moment.
+//
+//	it := pg.Iterator(ctx)
+//	for it.Next() {
+//		fmt.Println(it.Password())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	ctx      context.Context
+	pg       *PwGen
+	n        int
+	password []byte
+	err      error
+}
+
+// Iterator returns a new passwords iterator which stops when the context is done.
+func (pg *PwGen) Iterator(ctx context.Context) *Iterator {
+	return &Iterator{ctx: ctx, pg: pg}
+}
+
+// Next generates a new password, it returns false if all passwords are generated or an error occurred.
+// The buffer of the previous password is wiped.
+func (it *Iterator) Next() bool {
+	wipe(it.password)
+	it.password = nil
+	if it.err != nil || it.n >= it.pg.numPw {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	it.password, it.err = it.pg.generateBytes()
+	if it.err != nil {
+		return false
+	}
+	it.n++
+	return true
+}
+
+// Password returns the last generated password.
+func (it *Iterator) Password() string {
+	return string(it.password)
+}
+
+// Bytes returns a buffer of the last generated password,
+// it's wiped by the next call of Next, so it must not be kept by the caller.
+func (it *Iterator) Bytes() []byte {
+	return it.password
+}
+
+// Err returns an error which stopped the iteration,
+// it's an error of the context or *RandomError if the random source failed.
+func (it *Iterator) Err() error {
+	return it.err
+}
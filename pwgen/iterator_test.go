@@ -0,0 +1,130 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// failWriter returns an error after n writes.
+type failWriter struct {
+	n int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, errors.New("write error")
+	}
+	w.n--
+	return len(p), nil
+}
+
+// waitGoroutines waits until number of goroutines is not greater than n.
+func waitGoroutines(t *testing.T, n int) {
+	for i := 0; i < 100; i++ {
+		if runtime.NumGoroutine() <= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("goroutines leak: %v > %v", runtime.NumGoroutine(), n)
+}
+
+func TestIterator(t *testing.T) {
+	pg, err := NewWithOptions(WithLength(10), WithNumber(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	it := pg.Iterator(context.Background())
+	for it.Next() {
+		if l := len(it.Password()); l != 10 {
+			t.Errorf("%v failed len=%v", it.Password(), l)
+		}
+		n++
+	}
+	if err = it.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n != 50 {
+		t.Errorf("unexpected number of passwords: %v", n)
+	}
+	if it.Next() {
+		t.Error("unexpected next password")
+	}
+}
+
+func TestIteratorCancel(t *testing.T) {
+	pg, err := NewWithOptions(WithNumber(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	it := pg.Iterator(ctx)
+	for it.Next() {
+		n++
+		if n == 10 {
+			cancel()
+		}
+	}
+	if n != 10 {
+		t.Errorf("unexpected number of passwords: %v", n)
+	}
+	if err = it.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPasswordsContext(t *testing.T) {
+	pg, err := NewWithOptions(WithNumber(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := pg.PasswordsContext(ctx)
+	for i := 0; i < 5; i++ {
+		if p := <-ch; len(p) != defaultPwLength {
+			t.Errorf("%v failed len=%v", p, len(p))
+		}
+	}
+	cancel()
+	for range ch {
+		// the channel is closed after the cancellation
+	}
+	waitGoroutines(t, n)
+
+	i := 0
+	for range pg.PasswordsContext(context.Background()) {
+		i++
+	}
+	if i != pg.numPw {
+		t.Errorf("unexpected number of passwords: %v", i)
+	}
+}
+
+func TestPrintContext(t *testing.T) {
+	pg, err := NewWithOptions(WithNumber(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := runtime.NumGoroutine()
+	if err = pg.Print(&failWriter{n: 3}); err == nil {
+		t.Error("no expected write error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = pg.PrintContext(ctx, ioutil.Discard); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	waitGoroutines(t, n)
+}
@@ -0,0 +1,471 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Output formats of generated passwords.
+const (
+	FormatText   = "text"   // space separated columns
+	FormatJSON   = "json"   // JSON array of records
+	FormatNDJSON = "ndjson" // one JSON record per line
+	FormatCSV    = "csv"    // CSV with a header row
+)
+
+// Record is a generated password with optional metadata.
+type Record struct {
+	Password     string  `json:"password"`
+	Length       int     `json:"length,omitempty"`
+	AlphabetSize int     `json:"alphabet_size,omitempty"`
+	Entropy      float64 `json:"entropy,omitempty"`             // bits
+	UpperBound   bool    `json:"entropy_upper_bound,omitempty"` // see PwGen.EntropyIsUpperBound
+	Hash         string  `json:"hash,omitempty"`
+}
+
+// Writer writes generated passwords records.
+type Writer interface {
+	// Write outputs a new record.
+	Write(r *Record) error
+	// Close completes the output, it doesn't close an underlying writer.
+	Close() error
+}
+
+// TextWriter writes passwords as space separated columns.
+type TextWriter struct {
+	out     io.Writer
+	columns int
+	n       int
+}
+
+// NewTextWriter returns a new text writer,
+// all passwords are written as one line if columns is not positive.
+func NewTextWriter(out io.Writer, columns int) *TextWriter {
+	return &TextWriter{out: out, columns: columns}
+}
+
+// Write outputs the password followed by its hash if it's set, metadata is ignored.
+func (w *TextWriter) Write(r *Record) error {
+	return w.writeSecret([]byte(r.Password), r)
+}
+
+// writeSecret outputs the password buffer like Write, the password of the record is ignored.
+func (w *TextWriter) writeSecret(password []byte, r *Record) error {
+	line := make([]byte, 0, len(password)+len(r.Hash)+2)
+	defer func() { wipe(line) }()
+	line = append(line, password...)
+	if r.Hash != "" {
+		line = append(line, ' ')
+		line = append(line, r.Hash...)
+	}
+	w.n++
+	if w.columns > 0 && w.n%w.columns == 0 {
+		line = append(line, '\n')
+	} else {
+		line = append(line, ' ')
+	}
+	_, err := w.out.Write(line)
+	return err
+}
+
+// Close outputs a new line if it's needed.
+func (w *TextWriter) Close() error {
+	if w.columns > 0 && w.n > 0 && w.n%w.columns == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintln(w.out)
+	return err
+}
+
+// marshalJSON returns JSON of the value without HTML escaping.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte{'\n'}), nil
+}
+
+// rawWriter is a writer of already marshaled JSON values.
+type rawWriter interface {
+	Writer
+	writeRaw(data []byte) error
+}
+
+// secretWriter is a writer of records with passwords in byte buffers,
+// it doesn't make string copies of passwords, so they can be wiped after the output.
+type secretWriter interface {
+	Writer
+	writeSecret(password []byte, r *Record) error
+}
+
+// jsonPasswordPrefix is a beginning of JSON records with an empty password.
+const jsonPasswordPrefix = `{"password":"`
+
+// marshalSecret returns JSON of the record with the password buffer,
+// the password of the record is ignored. The result should be wiped after usage.
+func marshalSecret(password []byte, r *Record) ([]byte, error) {
+	record := *r
+	record.Password = ""
+	data, err := marshalJSON(&record)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(jsonPasswordPrefix)) {
+		return nil, fmt.Errorf("unexpected JSON record %q", data)
+	}
+	// a password can be escaped up to 6 bytes per char
+	result := make([]byte, 0, len(data)+6*len(password))
+	result = append(result, jsonPasswordPrefix...)
+	result = appendJSONString(result, password)
+	return append(result, data[len(jsonPasswordPrefix):]...), nil
+}
+
+// JSONWriter writes passwords as JSON array of records.
+type JSONWriter struct {
+	out io.Writer
+	n   int
+}
+
+// NewJSONWriter returns a new JSON writer.
+func NewJSONWriter(out io.Writer) *JSONWriter {
+	return &JSONWriter{out: out}
+}
+
+// Write outputs the record as an item of the array.
+func (w *JSONWriter) Write(r *Record) error {
+	data, err := marshalJSON(r)
+	if err != nil {
+		return err
+	}
+	return w.writeRaw(data)
+}
+
+// writeSecret outputs the record with the password buffer as an item of the array.
+func (w *JSONWriter) writeSecret(password []byte, r *Record) error {
+	data, err := marshalSecret(password, r)
+	if err != nil {
+		return err
+	}
+	defer wipe(data)
+	return w.writeRaw(data)
+}
+
+// writeRaw outputs JSON data as an item of the array.
+func (w *JSONWriter) writeRaw(data []byte) error {
+	prefix := ",\n  "
+	if w.n == 0 {
+		prefix = "[\n  "
+	}
+	w.n++
+	if _, err := io.WriteString(w.out, prefix); err != nil {
+		return err
+	}
+	_, err := w.out.Write(data)
+	return err
+}
+
+// Close completes the array.
+func (w *JSONWriter) Close() error {
+	suffix := "\n]\n"
+	if w.n == 0 {
+		suffix = "[]\n"
+	}
+	_, err := io.WriteString(w.out, suffix)
+	return err
+}
+
+// NDJSONWriter writes passwords as JSON records one per line.
+type NDJSONWriter struct {
+	out io.Writer
+}
+
+// NewNDJSONWriter returns a new newline delimited JSON writer.
+func NewNDJSONWriter(out io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{out: out}
+}
+
+// Write outputs the record as a line.
+func (w *NDJSONWriter) Write(r *Record) error {
+	data, err := marshalJSON(r)
+	if err != nil {
+		return err
+	}
+	return w.writeRaw(data)
+}
+
+// writeSecret outputs the record with the password buffer as a line.
+func (w *NDJSONWriter) writeSecret(password []byte, r *Record) error {
+	data, err := marshalSecret(password, r)
+	if err != nil {
+		return err
+	}
+	defer wipe(data)
+	return w.writeRaw(data)
+}
+
+// writeRaw outputs JSON data as a line.
+func (w *NDJSONWriter) writeRaw(data []byte) error {
+	if _, err := w.out.Write(data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, "\n")
+	return err
+}
+
+// Close does nothing, all records are already written.
+func (w *NDJSONWriter) Close() error {
+	return nil
+}
+
+// CSVWriter writes passwords as CSV rows with a header.
+// Fields are quoted like encoding/csv does, rows are ended by a new line.
+type CSVWriter struct {
+	out      io.Writer
+	metadata bool
+	hash     bool
+	header   bool
+}
+
+// NewCSVWriter returns a new CSV writer, metadata and hash columns are included if they're required.
+func NewCSVWriter(out io.Writer, metadata, hash bool) *CSVWriter {
+	return &CSVWriter{out: out, metadata: metadata, hash: hash}
+}
+
+// writeHeader outputs the header row once.
+func (w *CSVWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	header := "password"
+	if w.metadata {
+		header += ",length,alphabet_size,entropy,entropy_upper_bound"
+	}
+	if w.hash {
+		header += ",hash"
+	}
+	_, err := io.WriteString(w.out, header+"\n")
+	return err
+}
+
+// Write outputs the record as a row.
+func (w *CSVWriter) Write(r *Record) error {
+	return w.writeSecret([]byte(r.Password), r)
+}
+
+// writeSecret outputs the record with the password buffer as a row, the password of the record is ignored.
+func (w *CSVWriter) writeSecret(password []byte, r *Record) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var fields []string
+	if w.metadata {
+		fields = append(fields,
+			strconv.Itoa(r.Length),
+			strconv.Itoa(r.AlphabetSize),
+			strconv.FormatFloat(r.Entropy, 'f', 2, 64),
+			strconv.FormatBool(r.UpperBound),
+		)
+	}
+	if w.hash {
+		fields = append(fields, r.Hash)
+	}
+	// a quoted field is not longer than twice a value with quotes and a separator
+	size := 2*len(password) + 3
+	for _, field := range fields {
+		size += 2*len(field) + 3
+	}
+	row := make([]byte, 0, size)
+	defer func() { wipe(row) }()
+	row = appendCSVField(row, password)
+	for _, field := range fields {
+		row = appendCSVField(append(row, ','), []byte(field))
+	}
+	_, err := w.out.Write(append(row, '\n'))
+	return err
+}
+
+// Close outputs the header if there were no records.
+func (w *CSVWriter) Close() error {
+	return w.writeHeader()
+}
+
+// csvNeedsQuotes returns true if the CSV field has to be quoted,
+// it follows rules of encoding/csv writer with the comma separator.
+func csvNeedsQuotes(value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+	if string(value) == `\.` || bytes.ContainsAny(value, ",\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRune(value)
+	return unicode.IsSpace(r)
+}
+
+// appendCSVField appends the value as a CSV field, it's quoted if it's needed.
+func appendCSVField(dst, value []byte) []byte {
+	if !csvNeedsQuotes(value) {
+		return append(dst, value...)
+	}
+	dst = append(dst, '"')
+	for _, c := range value {
+		if c == '"' {
+			dst = append(dst, '"')
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, '"')
+}
+
+// validFormat returns true if the output format is supported, empty one is the text format.
+func validFormat(format string) bool {
+	switch format {
+	case "", FormatText, FormatJSON, FormatNDJSON, FormatCSV:
+		return true
+	}
+	return false
+}
+
+// NewWriter returns a writer of the configured output format.
+func (pg *PwGen) NewWriter(out io.Writer) Writer {
+	switch pg.format {
+	case FormatJSON:
+		return NewJSONWriter(out)
+	case FormatNDJSON:
+		return NewNDJSONWriter(out)
+	case FormatCSV:
+		return NewCSVWriter(out, pg.metadata, pg.hash != "")
+	}
+	if pg.hash != "" {
+		// a password and its hash per line
+		return NewTextWriter(out, 1)
+	}
+	if pg.oneLine && !pg.onePerLine {
+		return NewTextWriter(out, 0)
+	}
+	return NewTextWriter(out, pg.lineColumns(out))
+}
+
+// terminalWidth returns a width of the terminal or false if the file is not a terminal.
+// A positive COLUMNS environment variable has priority over the terminal size.
+func terminalWidth(f *os.File) (int, bool) {
+	fd := int(f.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n, true
+	}
+	if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+		return width, true
+	}
+	return screenWidth, true
+}
+
+// lineColumns returns a number of passwords per line of the text output.
+// Passwords are separated by spaces, so lines are not longer than the screen width.
+// The screen width is detected for terminals, other files get one password per line
+// like the original pwgen unless columns are required, other writers use the default width.
+func (pg *PwGen) lineColumns(out io.Writer) int {
+	if pg.onePerLine {
+		return 1
+	}
+	width := pg.screenWidth
+	if width == 0 {
+		width = screenWidth
+		if f, ok := out.(*os.File); ok {
+			w, ok := terminalWidth(f)
+			if !ok && !pg.columns {
+				return 1
+			}
+			if ok {
+				width = w
+			}
+		}
+	}
+	if columns := (width + 1) / (pg.width() + 1); columns > 1 {
+		return columns
+	}
+	return 1
+}
+
+// WriteContext writes required passwords until the context is done and completes the output.
+// Records contain metadata and password hashes if they're configured.
+// Text, JSON and CSV writers get passwords as byte buffers which are wiped after the output,
+// so no string copies of passwords are made.
+// Passwords and their hashes are generated by parallel workers if it's configured, see WithWorkers.
+func (pg *PwGen) WriteContext(ctx context.Context, w Writer) error {
+	sw, secret := w.(secretWriter)
+	err := pg.generateAll(ctx, true, func(password []byte, r *Record) error {
+		if secret {
+			return sw.writeSecret(password, r)
+		}
+		r.Password = string(password)
+		return w.Write(r)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// newRecord returns a record of the password with metadata and hash if they're configured,
+// the password itself is not set.
+func (pg *PwGen) newRecord(password []byte, size int, entropy float64) (*Record, error) {
+	r := &Record{}
+	if pg.metadata {
+		r.Length, r.AlphabetSize, r.Entropy = len(password), size, entropy
+		r.UpperBound = pg.EntropyIsUpperBound()
+	}
+	if pg.hash != "" {
+		h, err := HashPassword(pg.hash, password)
+		if err != nil {
+			return nil, err
+		}
+		r.Hash = h
+	}
+	return r, nil
+}
+
+// alphabetSize returns a number of different chars or words which are used in passwords.
+func (pg *PwGen) alphabetSize() int {
+	if pg.words > 0 {
+		words := make(map[string]struct{}, len(pg.wordList))
+		for _, word := range pg.wordList {
+			words[word] = struct{}{}
+		}
+		return len(words)
+	}
+	var chars [256]bool
+	alphabets := [][]byte{pg.chars}
+	if pg.pattern != nil {
+		alphabets = pg.pattern
+	}
+	n := 0
+	for _, alphabet := range alphabets {
+		for _, c := range alphabet {
+			if !chars[c] {
+				chars[c] = true
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormats(t *testing.T) {
+	values := []struct {
+		opts     []Option
+		expected string
+	}{
+		{[]Option{WithFormat(FormatText)}, "ha9iFohd kaeTieg0 she6Do5e \n"},
+		{[]Option{WithFormat(FormatJSON)},
+			"[\n  {\"password\":\"ha9iFohd\"},\n  {\"password\":\"kaeTieg0\"},\n  {\"password\":\"she6Do5e\"}\n]\n"},
+		{[]Option{WithFormat(FormatNDJSON)},
+			"{\"password\":\"ha9iFohd\"}\n{\"password\":\"kaeTieg0\"}\n{\"password\":\"she6Do5e\"}\n"},
+		{[]Option{WithFormat(FormatCSV)}, "password\nha9iFohd\nkaeTieg0\nshe6Do5e\n"},
+		{[]Option{WithFormat(FormatText), WithOneLine(false), WithLength(30)},
+			"oaHacuvahpheik5sa9iFohdiengoh1 ehuuhaeThohc8zae2zu5pai1yiepas\naes2eethoomaeTieg0eGho5ohF0eeN \n"},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(append([]Option{WithSeed(42), WithNumber(3), WithOneLine(true)}, v.opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err = pg.Print(&b); err != nil {
+			t.Fatal(err)
+		}
+		if s := b.String(); s != v.expected {
+			t.Errorf("[%v] unexpected output %q", i, s)
+		}
+	}
+	_, err := NewWithOptions(WithFormat("xml"))
+	if !errors.Is(err, ErrFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOutputMetadata(t *testing.T) {
+	opts := []Option{WithSecure(true), WithSymbols(true), WithNumber(10), WithMetadata(true)}
+	pg, err := NewWithOptions(append(opts, WithFormat(FormatJSON))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = pg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	if err = json.Unmarshal(b.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(records); n != 10 {
+		t.Fatalf("unexpected number of records %v", n)
+	}
+	for _, r := range records {
+		if r.Length != defaultPwLength || len(r.Password) != r.Length {
+			t.Errorf("unexpected length %+v", r)
+		}
+		if r.AlphabetSize != 94 {
+			t.Errorf("unexpected alphabet size %+v", r)
+		}
+		if math.Abs(r.Entropy-pg.Entropy()) > 0.005 || r.UpperBound {
+			t.Errorf("unexpected entropy %+v", r)
+		}
+	}
+	// pronounceable passwords entropy is marked as an upper bound
+	pg, err = NewWithOptions(WithNumber(2), WithMetadata(true), WithFormat(FormatNDJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err = pg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), `"entropy_upper_bound":true`); n != 2 {
+		t.Errorf("unexpected output %q", b.String())
+	}
+	// quoted special chars
+	pg, err = NewWithOptions(append(opts, WithFormat(FormatCSV), WithPattern("s{5}"))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err = pg.Print(&b); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(rows[0], ","); s != "password,length,alphabet_size,entropy,entropy_upper_bound" {
+		t.Errorf("unexpected header %v", s)
+	}
+	if n := len(rows); n != 11 {
+		t.Fatalf("unexpected number of rows %v", n)
+	}
+	for _, row := range rows[1:] {
+		if len(row[0]) != 5 || row[1] != "5" || row[2] != "32" || row[4] != "false" {
+			t.Errorf("unexpected row %v", row)
+		}
+	}
+	pg, err = NewWithOptions(append(opts, WithWords(3))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := pg.alphabetSize(); n != 7776 {
+		t.Errorf("unexpected alphabet size %v", n)
+	}
+}
+
+func TestOutputEmpty(t *testing.T) {
+	var b bytes.Buffer
+	writers := []Writer{NewJSONWriter(&b), NewNDJSONWriter(&b), NewCSVWriter(&b, false, false), NewTextWriter(&b, 3)}
+	for _, w := range writers {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := b.String(); s != "[]\npassword\n\n" {
+		t.Errorf("unexpected output %q", s)
+	}
+}
+
+func TestOutputColumns(t *testing.T) {
+	f, err := os.CreateTemp("", "pwgen_columns_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Error(err)
+		}
+		if err := os.Remove(f.Name()); err != nil {
+			t.Error(err)
+		}
+	}()
+	values := []struct {
+		opts    []Option
+		out     io.Writer
+		columns int
+	}{
+		{nil, io.Discard, 9},
+		{[]Option{WithLength(10)}, io.Discard, 7},
+		{[]Option{WithLength(9)}, io.Discard, 8},
+		{[]Option{WithLength(100)}, io.Discard, 1},
+		{[]Option{WithWidth(17)}, io.Discard, 2},
+		{[]Option{WithWidth(200)}, io.Discard, 22},
+		// not a terminal
+		{nil, f, 1},
+		{[]Option{WithWidth(80)}, f, 9},
+		{[]Option{WithColumns(true)}, f, 9},
+		{[]Option{WithOnePerLine(true), WithColumns(true)}, f, 1},
+		{[]Option{WithOnePerLine(true), WithWidth(80)}, io.Discard, 1},
+		{[]Option{WithWords(5)}, io.Discard, 1},
+		{[]Option{WithWords(2), WithWordList(WordListShort), WithWidth(120)}, io.Discard, 5},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(v.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := pg.lineColumns(v.out); n != v.columns {
+			t.Errorf("[%v] unexpected columns %v", i, n)
+		}
+	}
+	pg, err := NewWithOptions(WithNumber(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pg.Print(f); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 30 {
+		t.Errorf("unexpected number of lines %v", len(lines))
+	}
+	_, err = NewWithOptions(WithWidth(-1))
+	if !errors.Is(err, ErrWidth) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
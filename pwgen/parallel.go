@@ -0,0 +1,224 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// parallelBuffer is a number of generated passwords which a worker can keep before the output.
+const parallelBuffer = 64
+
+// splitter is a deterministic random reader which can be split into independent ones.
+type splitter interface {
+	io.Reader
+	// split returns a new reader seeded by the output of the current one.
+	split() io.Reader
+}
+
+// lockedReader is a random reader which is safe for concurrent use.
+type lockedReader struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+// Read fills p by random bytes of the underlying reader.
+func (l *lockedReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
+
+// lockedSource is a source of pseudo-random values which is safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+// Int63 returns a non-negative random 63-bit integer of the underlying source.
+func (l *lockedSource) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Int63()
+}
+
+// Seed sets a seed of the underlying source.
+func (l *lockedSource) Seed(seed int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.src.Seed(seed)
+}
+
+// Fork returns a copy of the generator with its own random source,
+// so the copy and pg can be used by different goroutines.
+// Deterministic sources of seeded, SHA-1 file and site-specific passwords are split by their output,
+// forks of the same generator produce the same passwords in the same order.
+// Forks of the secure mode get new CSPRNGs seeded from crypto/rand, so they don't wait for the shared one.
+// Custom sources and readers are synchronized and shared.
+// Fork changes the state of pg, so it must not be called concurrently with other pg methods.
+func (pg *PwGen) Fork() *PwGen {
+	f := *pg
+	switch r := pg.reader.(type) {
+	case splitter:
+		f.reader = r.split()
+	case *CSPRNG:
+		f.reader = NewCSPRNG()
+	case nil:
+		switch pg.source.(type) {
+		case CryptoRandSource, *lockedSource:
+			// shared sources are safe, rand.Rand has no state without Read calls
+		default:
+			f.source = rand.NewSource(pg.random.Int63())
+			f.random = rand.New(f.source)
+		}
+	}
+	return &f
+}
+
+// parallelItem is a password buffer and its record generated by a worker.
+type parallelItem struct {
+	password []byte
+	record   *Record
+	err      error
+}
+
+// generateAll generates required passwords until the context is done and calls fn for every one,
+// records with metadata and hashes are prepared if they're required.
+// Buffers are wiped after fn calls. Passwords are generated by parallel workers if it's configured.
+func (pg *PwGen) generateAll(ctx context.Context, records bool, fn func(password []byte, r *Record) error) error {
+	var (
+		size    int
+		entropy float64
+	)
+	if records && pg.metadata {
+		// entropy is rounded to hundredths of bits
+		size, entropy = pg.alphabetSize(), math.Round(pg.Entropy()*100)/100
+	}
+	if pg.workers > 1 && pg.numPw > 1 {
+		return pg.generateParallel(ctx, records, size, entropy, fn)
+	}
+	it := pg.Iterator(ctx)
+	for it.Next() {
+		password := it.Bytes()
+		r := &Record{}
+		if records {
+			var err error
+			if r, err = pg.newRecord(password, size, entropy); err != nil {
+				return err
+			}
+		}
+		if err := fn(password, r); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// generateParallel is generateAll by workers with forked generators.
+// The i-th password is generated by (i % workers) worker, so the ordered output
+// of deterministic modes depends only on a number of workers.
+func (pg *PwGen) generateParallel(ctx context.Context, records bool, size int, entropy float64,
+	fn func(password []byte, r *Record) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := pg.workers
+	if workers > pg.numPw {
+		workers = pg.numPw
+	}
+	var (
+		wg       sync.WaitGroup
+		channels []chan parallelItem
+	)
+	if pg.unordered {
+		channels = []chan parallelItem{make(chan parallelItem, workers*parallelBuffer)}
+	}
+	for k := 0; k < workers; k++ {
+		var c chan parallelItem
+		if pg.unordered {
+			c = channels[0]
+		} else {
+			c = make(chan parallelItem, parallelBuffer)
+			channels = append(channels, c)
+		}
+		n := pg.numPw / workers
+		if k < pg.numPw%workers {
+			n++
+		}
+		wg.Add(1)
+		go func(fork *PwGen, n int, c chan<- parallelItem) {
+			defer wg.Done()
+			if !pg.unordered {
+				defer close(c)
+			}
+			fork.work(ctx, n, records, size, entropy, c)
+		}(pg.Fork(), n, c)
+	}
+	if pg.unordered {
+		// the shared channel is closed when all workers are stopped
+		go func() {
+			wg.Wait()
+			close(channels[0])
+		}()
+	}
+	err := collect(ctx, pg.numPw, channels, fn)
+	cancel()
+	drain(channels)
+	return err
+}
+
+// work generates n passwords and sends them to c until the context is done or an error occurred.
+func (pg *PwGen) work(ctx context.Context, n int, records bool, size int, entropy float64, c chan<- parallelItem) {
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		item := parallelItem{record: &Record{}}
+		item.password, item.err = pg.generateBytes()
+		if item.err == nil && records {
+			item.record, item.err = pg.newRecord(item.password, size, entropy)
+		}
+		select {
+		case c <- item:
+		case <-ctx.Done():
+			wipe(item.password)
+			return
+		}
+		if item.err != nil {
+			return
+		}
+	}
+}
+
+// collect calls fn for n passwords of workers channels, they are read by turns if there are several channels.
+// It returns an error of a worker, fn or the context if channels are closed earlier.
+func collect(ctx context.Context, n int, channels []chan parallelItem,
+	fn func(password []byte, r *Record) error) error {
+	for i := 0; i < n; i++ {
+		item, ok := <-channels[i%len(channels)]
+		if !ok {
+			return ctx.Err()
+		}
+		err := item.err
+		if err == nil {
+			err = fn(item.password, item.record)
+		}
+		wipe(item.password)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drain wipes not used passwords of stopped workers until channels are closed.
+func drain(channels []chan parallelItem) {
+	for _, c := range channels {
+		for item := range c {
+			wipe(item.password)
+		}
+	}
+}
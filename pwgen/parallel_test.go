@@ -0,0 +1,207 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFork(t *testing.T) {
+	values := [][]Option{
+		{WithSeed(42)},
+		{WithSeed(42), WithSecure(true)},
+		{WithSite([]byte("master"), "example.com", "user", 1)},
+		{WithRandSource(rand.NewSource(42))},
+		{WithSecure(true)},
+		{},
+	}
+	for i, opts := range values {
+		pg, err := NewWithOptions(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forks := []*PwGen{pg.Fork(), pg.Fork(), pg.Fork(), pg}
+		passwords := make([][]string, len(forks))
+		var wg sync.WaitGroup
+		for k, fork := range forks {
+			wg.Add(1)
+			go func(k int, fork *PwGen) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					passwords[k] = append(passwords[k], fork.Generate())
+				}
+			}(k, fork)
+		}
+		wg.Wait()
+		if a, b := strings.Join(passwords[0], " "), strings.Join(passwords[1], " "); a == b {
+			t.Errorf("[%v] forks have the same passwords", i)
+		}
+	}
+	// deterministic forks
+	var expected []string
+	for i := 0; i < 2; i++ {
+		pg, err := NewWithOptions(WithSeed(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fork := pg.Fork()
+		passwords := []string{fork.Generate(), fork.Generate(), pg.Generate()}
+		if i == 0 {
+			expected = passwords
+		} else if a, b := strings.Join(passwords, " "), strings.Join(expected, " "); a != b {
+			t.Errorf("unexpected passwords %v, expected %v", a, b)
+		}
+	}
+}
+
+func TestPrintWorkers(t *testing.T) {
+	const number = 1000
+	output := func(opts ...Option) []string {
+		pg, err := NewWithOptions(append([]Option{WithNumber(number), WithOnePerLine(true)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err = pg.Print(&b); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Fields(b.String())
+	}
+	sequential := output(WithSeed(42))
+	if n := len(sequential); n != number {
+		t.Fatalf("unexpected number %v", n)
+	}
+	for _, workers := range []int{2, 3, 8, 2 * number} {
+		ordered := output(WithSeed(42), WithWorkers(workers))
+		if n := len(ordered); n != number {
+			t.Errorf("[%v] unexpected number %v", workers, n)
+		}
+		if a, b := strings.Join(ordered, " "), strings.Join(output(WithSeed(42), WithWorkers(workers)), " "); a != b {
+			t.Errorf("[%v] ordered output is not reproducible", workers)
+		}
+		unordered := output(WithSeed(42), WithWorkers(workers), WithUnordered(true))
+		sort.Strings(ordered)
+		sort.Strings(unordered)
+		if a, b := strings.Join(ordered, " "), strings.Join(unordered, " "); a != b {
+			t.Errorf("[%v] unordered output has other passwords", workers)
+		}
+	}
+	// one worker is the sequential generation
+	if a, b := strings.Join(output(WithSeed(42), WithWorkers(1)), " "), strings.Join(sequential, " "); a != b {
+		t.Error("unexpected output of one worker")
+	}
+	passwords := output(WithSecure(true), WithWorkers(4), WithUnordered(true), WithHash(HashSHA1), WithFormat(FormatCSV))
+	if n := len(passwords); n != number+1 {
+		t.Errorf("unexpected number %v", n)
+	}
+	for _, p := range passwords[1:] {
+		fields := strings.Split(p, ",")
+		if h, err := HashPassword(HashSHA1, []byte(fields[0])); err != nil || h != fields[1] {
+			t.Errorf("unexpected hash %q of %q", fields[1], fields[0])
+		}
+	}
+}
+
+func TestPrintWorkersErrors(t *testing.T) {
+	n := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, unordered := range []bool{false, true} {
+		pg, err := NewWithOptions(WithNumber(1000), WithWorkers(4), WithUnordered(unordered))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = pg.PrintContext(ctx, io.Discard); !errors.Is(err, context.Canceled) {
+			t.Errorf("[%v] unexpected error: %v", unordered, err)
+		}
+		if err = pg.Print(&failWriter{n: 10}); err == nil {
+			t.Errorf("[%v] no expected write error", unordered)
+		}
+		pg, err = NewWithOptions(WithNumber(1000), WithWorkers(4), WithUnordered(unordered), WithReader(&failReader{n: 400}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var (
+			b  bytes.Buffer
+			re *RandomError
+		)
+		if err = pg.Print(&b); !errors.As(err, &re) || !errors.Is(err, errRead) {
+			t.Errorf("[%v] unexpected error: %v", unordered, err)
+		}
+	}
+	waitGoroutines(t, n)
+	_, err := NewWithOptions(WithWorkers(-1))
+	if !errors.Is(err, ErrWorkers) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPasswordsWorkers(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	pg, err := NewWithOptions(WithNumber(100), WithWorkers(4), WithSeed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for p := range pg.PasswordsContext(context.Background()) {
+		if len(p) != defaultPwLength {
+			t.Errorf("unexpected password %q", p)
+		}
+		n++
+	}
+	if n != 100 {
+		t.Errorf("unexpected number %v", n)
+	}
+	n = 0
+	for range pg.Passwords() {
+		n++
+	}
+	if n != 100 {
+		t.Errorf("unexpected number %v", n)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := pg.PasswordsContext(ctx)
+	<-c
+	cancel()
+	for range c {
+	}
+	waitGoroutines(t, goroutines)
+}
+
+func BenchmarkPrintWorkers(b *testing.B) {
+	values := []struct {
+		name string
+		opts []Option
+	}{
+		{"secure", []Option{WithSecure(true), WithLength(16)}},
+		{"phonemes", nil},
+		{"sha512-crypt", []Option{WithSecure(true), WithHash(HashSHA512)}},
+	}
+	for _, v := range values {
+		for _, workers := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("%s/workers-%d", v.name, workers), func(b *testing.B) {
+				opts := append([]Option{WithNumber(b.N), WithOnePerLine(true), WithWorkers(workers)}, v.opts...)
+				pg, err := NewWithOptions(opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ResetTimer()
+				if err = pg.Print(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bufio"
+	_ "embed" // embedded word lists
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultNumWords  = 5   // default number of words in passphrases
+	defaultSeparator = "-" // default words separator
+
+	// WordListLarge is a name of embedded EFF's large word list, 7776 words.
+	WordListLarge = "eff-large"
+	// WordListShort is a name of embedded EFF's short word list 2.0, 1296 words.
+	WordListShort = "eff-short"
+)
+
+var (
+	//go:embed wordlists/eff_large_wordlist.txt
+	effLargeWordList string
+	//go:embed wordlists/eff_short_wordlist_2_0.txt
+	effShortWordList string
+
+	// parsed embedded word lists
+	embeddedWordLists = map[string]*embeddedWordList{
+		WordListLarge: {data: effLargeWordList},
+		WordListShort: {data: effShortWordList},
+	}
+)
+
+// embeddedWordList is a lazy parsed word list.
+type embeddedWordList struct {
+	once  sync.Once
+	data  string
+	words []string
+}
+
+// list returns words of the embedded list.
+func (e *embeddedWordList) list() []string {
+	e.once.Do(func() {
+		// it can't fail for a strings reader
+		e.words, _ = ReadWordList(strings.NewReader(e.data))
+	})
+	return e.words
+}
+
+// ReadWordList reads words from r, one word per line.
+// Dice rolls prefixes of EFF's lists format, empty lines and "#" comments are skipped.
+func ReadWordList(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		words = append(words, fields[len(fields)-1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, ErrWordList
+	}
+	return words, nil
+}
+
+// LoadWordList returns words of the embedded list by its name or reads them from a file.
+// The large EFF's word list is returned for an empty name.
+func LoadWordList(name string) ([]string, error) {
+	if name == "" {
+		name = WordListLarge
+	}
+	if e, ok := embeddedWordLists[name]; ok {
+		return e.list(), nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	words, err := ReadWordList(f)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return nil, err
+	}
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// GeneratePassphrase returns a new passphrase of random words.
+// Words are capitalized if capital letters are allowed,
+// a digit and a special character are appended to random words
+// if numerals and symbols are required.
+// The default number of words and the large EFF's word list are used
+// if passphrases generation is not configured.
+// Errors are the same as TryGenerate ones, passphrases are screened by the blocklist and the breach list too.
+func (pg *PwGen) GeneratePassphrase() (string, error) {
+	password, err := pg.generateScreened(func() ([]byte, error) {
+		return pg.generatePassphrase(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer wipe(password)
+	return string(password), nil
+}
+
+// generatePassphrase returns a new passphrase buffer like GeneratePassphrase.
+func (pg *PwGen) generatePassphrase() []byte {
+	words, list := pg.words, pg.wordList
+	if words < 1 {
+		words = defaultNumWords
+	}
+	if list == nil {
+		list = embeddedWordLists[WordListLarge].list()
+	}
+	parts := make([]string, words)
+	for i := range parts {
+		parts[i] = list[pg.intn(len(list))]
+	}
+	// extra chars which are appended to words
+	var extra [2]struct {
+		word int
+		char byte
+	}
+	n := 0
+	if pg.numerals {
+		if digits := pg.filter(pwDigits); len(digits) > 0 {
+			extra[n].word = pg.intn(words)
+			extra[n].char = pg.choice(digits)
+			n++
+		}
+	}
+	if pg.symbols {
+		if symbols := pg.filter(pwSymbols); len(symbols) > 0 {
+			extra[n].word = pg.intn(words)
+			extra[n].char = pg.choice(symbols)
+			n++
+		}
+	}
+	size := (words - 1) * len(pg.separator)
+	for _, word := range parts {
+		size += len(word)
+	}
+	password := make([]byte, 0, size+n)
+	for i, word := range parts {
+		if i > 0 {
+			password = append(password, pg.separator...)
+		}
+		start := len(password)
+		password = append(password, word...)
+		if !pg.noCapitalize {
+			password[start] = upperByte(password[start])
+		}
+		for _, e := range extra[:n] {
+			if e.word == i {
+				password = append(password, e.char)
+			}
+		}
+	}
+	return password
+}
+
+// upperByte returns an upper case ASCII letter or c as is.
+func upperByte(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// passphraseWidth returns a maximum length of passphrases.
+func (pg *PwGen) passphraseWidth() int {
+	var n int
+	for _, word := range pg.wordList {
+		if len(word) > n {
+			n = len(word)
+		}
+	}
+	n = pg.words*(n+len(pg.separator)) - len(pg.separator)
+	if pg.numerals {
+		n++
+	}
+	if pg.symbols {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestReadWordList(t *testing.T) {
+	data := "# comment\n11111\tabacus\n\n  11112 abdomen \nword\n"
+	words, err := ReadWordList(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(words, ","); s != "abacus,abdomen,word" {
+		t.Errorf("unexpected words: %v", s)
+	}
+	_, err = ReadWordList(strings.NewReader("# only comment\n\n"))
+	if !errors.Is(err, ErrWordList) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadWordList(t *testing.T) {
+	values := map[string]int{"": 7776, WordListLarge: 7776, WordListShort: 1296}
+	for name, n := range values {
+		words, err := LoadWordList(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l := len(words); l != n {
+			t.Errorf("unexpected number of words in %q: %v", name, l)
+		}
+	}
+	fullName := path.Join(os.TempDir(), "pwgen_words_test.tmp")
+	err := os.WriteFile(fullName, []byte("one\ntwo\nthree\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(fullName); err != nil {
+			t.Error(err)
+		}
+	}()
+	words, err := LoadWordList(fullName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(words, ","); s != "one,two,three" {
+		t.Errorf("unexpected words: %v", s)
+	}
+	_, err = NewWithOptions(WithWords(3), WithWordList("/root/bad_123"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = NewWithOptions(WithWords(-1))
+	if !errors.Is(err, ErrWords) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	pg, err := NewWithOptions(WithWords(4), WithWordList(WordListShort), WithSymbols(true), WithSeparator(" "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digits := []byte(pwDigits)
+	symbols := []byte(pwSymbols)
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i] < symbols[j] })
+
+	for p := range pg.Passwords() {
+		parts := strings.Split(p, " ")
+		if n := len(parts); n != 4 {
+			t.Errorf("%v failed words=%v", p, n)
+		}
+		for _, part := range parts {
+			if c := part[0]; c < 'A' || c > 'Z' {
+				t.Errorf("%v is not capitalized", p)
+			}
+		}
+		if !any(p, digits) {
+			t.Errorf("%v no digits", p)
+		}
+		if !any(p, symbols) {
+			t.Errorf("%v no symbols", p)
+		}
+		if l, w := len(p), pg.width(); l > w {
+			t.Errorf("%v failed len=%v > %v", p, l, w)
+		}
+	}
+}
+
+func TestPassphraseNoExtra(t *testing.T) {
+	pg, err := NewWithOptions(WithWords(3), WithNoCapitalize(true), WithNoNumerals(true), WithNumerals(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range pg.Passwords() {
+		for _, c := range []byte(p) {
+			if (c < 'a' || c > 'z') && c != '-' {
+				t.Errorf("%v unexpected char %q", p, c)
+			}
+		}
+		if n := strings.Count(p, defaultSeparator); n < 2 {
+			t.Errorf("%v failed separators=%v", p, n)
+		}
+	}
+	// not configured passphrase mode
+	pg, err = NewWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := pg.GeneratePassphrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Split(p, defaultSeparator)); n < defaultNumWords {
+		t.Errorf("unexpected number of words: %v", n)
+	}
+	pg, err = NewWithOptions(WithReader(&failReader{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var re *RandomError
+	if _, err = pg.GeneratePassphrase(); !errors.As(err, &re) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	name := path.Join(os.TempDir(), "pwgen_passphrase_blocklist_test.txt")
+	if err = os.WriteFile(name, []byte("a\ne\ni\no\nu\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(name); err != nil {
+			t.Error(err)
+		}
+	}()
+	pg, err = NewWithOptions(WithBlocklist(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pg.GeneratePassphrase(); !errors.Is(err, ErrBlocked) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxPatternRepeat is a maximum number of repetitions in a pattern.
+const maxPatternRepeat = 1024
+
+// patternClasses contains chars classes of patterns.
+var patternClasses = map[byte]string{
+	'c': "bcdfghjklmnpqrstvwxz",
+	'C': "BCDFGHJKLMNPQRSTVWXZ",
+	'v': "aeiouy",
+	'V': "AEIOUY",
+	'l': pwLowers,
+	'L': pwUppers,
+	'9': pwDigits,
+	's': pwSymbols,
+	'S': pwLowers + pwUppers + pwDigits + pwSymbols,
+}
+
+// compilePattern returns chars sets for every position of the pattern,
+// removeChars are excluded from the classes. It returns nil for an empty pattern.
+func compilePattern(pattern, removeChars string) ([][]byte, error) {
+	var result [][]byte
+	n := len(pattern)
+	for i := 0; i < n; i++ {
+		c := pattern[i]
+		switch c {
+		case '\\':
+			i++
+			if i == n {
+				return nil, fmt.Errorf("%w: unexpected end after escape", ErrPattern)
+			}
+			result = append(result, []byte{pattern[i]})
+		case '{':
+			j := strings.IndexByte(pattern[i:], '}')
+			if j < 0 {
+				return nil, fmt.Errorf("%w: not closed repetition at %d", ErrPattern, i)
+			}
+			if len(result) == 0 {
+				return nil, fmt.Errorf("%w: nothing to repeat at %d", ErrPattern, i)
+			}
+			count, err := strconv.Atoi(pattern[i+1 : i+j])
+			if err != nil || count < 1 || count > maxPatternRepeat {
+				return nil, fmt.Errorf("%w: bad repetition %q", ErrPattern, pattern[i:i+j+1])
+			}
+			last := result[len(result)-1]
+			for k := 1; k < count; k++ {
+				result = append(result, last)
+			}
+			i += j
+		default:
+			class, ok := patternClasses[c]
+			if !ok {
+				result = append(result, []byte{c})
+				continue
+			}
+			chars := make([]byte, 0, len(class))
+			for _, b := range []byte(class) {
+				if strings.IndexByte(removeChars, b) < 0 {
+					chars = append(chars, b)
+				}
+			}
+			if len(chars) == 0 {
+				return nil, fmt.Errorf("%w: no chars for %q", ErrPattern, c)
+			}
+			result = append(result, chars)
+		}
+	}
+	return result, nil
+}
+
+// generatePattern returns a new password matching the pattern.
+func (pg *PwGen) generatePattern() []byte {
+	password := make([]byte, len(pg.pattern))
+	for i, chars := range pg.pattern {
+		password[i] = pg.choice(chars)
+	}
+	return password
+}
+
+// GeneratePattern returns a new password matching the pattern
+// for the default configuration changed by options.
+//
+// Pattern chars:
+//
+//	c - lower consonant
+//	C - upper consonant
+//	v - lower vowel
+//	V - upper vowel
+//	l - lower letter
+//	L - upper letter
+//	9 - digit
+//	s - special character
+//	S - any letter, digit or special character
+//	\ - escape of the next char, it's used as is
+//	{n} - repeat the previous element n times
+//
+// All other chars are used as is, so "Cvccvc-9{2}-Ss" gives passwords like "Bihxol-47-K&".
+// Removed and ambiguous chars are excluded from the classes. An empty pattern is ErrPattern.
+func GeneratePattern(pattern string, opts ...Option) (string, error) {
+	if pattern == "" {
+		return "", &ConfigError{Field: "Pattern", Err: fmt.Errorf("%w: empty pattern", ErrPattern)}
+	}
+	opts = append(opts, WithPattern(pattern))
+	pg, err := NewWithOptions(opts...)
+	if err != nil {
+		return "", err
+	}
+	return pg.TryGenerate()
+}
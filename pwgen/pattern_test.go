@@ -0,0 +1,111 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompilePattern(t *testing.T) {
+	values := []struct {
+		pattern, removeChars string
+		sets                 []string
+	}{
+		{"", "", nil},
+		{"ab-", "", []string{"a", "b", "-"}},
+		{"v9", "", []string{"aeiouy", pwDigits}},
+		{"V\\9{2}", "AEI", []string{"OUY", "9", "9"}},
+		{"c{3}x", "bcdfghjklmnpqrst", []string{"vwxz", "vwxz", "vwxz", "x"}},
+		{"\\{s{1}", "", []string{"{", pwSymbols}},
+	}
+	for i, v := range values {
+		sets, err := compilePattern(v.pattern, v.removeChars)
+		if err != nil {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+			continue
+		}
+		if len(sets) != len(v.sets) {
+			t.Errorf("[%v] unexpected sets: %q", i, sets)
+			continue
+		}
+		for j := range sets {
+			if s := string(sets[j]); s != v.sets[j] {
+				t.Errorf("[%v] unexpected set %v: %q", i, j, s)
+			}
+		}
+	}
+}
+
+func TestCompilePatternFail(t *testing.T) {
+	values := []struct {
+		pattern, removeChars string
+	}{
+		{"abc\\", ""},
+		{"{2}", ""},
+		{"a{2", ""},
+		{"a{0}", ""},
+		{"a{x}", ""},
+		{"a{10000}", ""},
+		{"9", pwDigits},
+	}
+	for i, v := range values {
+		_, err := compilePattern(v.pattern, v.removeChars)
+		if !errors.Is(err, ErrPattern) {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestGeneratePattern(t *testing.T) {
+	pattern := "Cvccvc-9{2}-Ss"
+	pg, err := NewWithOptions(WithPattern(pattern), WithAmbiguous(true), WithRemoveChars("!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range pg.Passwords() {
+		if l := len(p); l != 12 {
+			t.Errorf("%v failed len=%v", p, l)
+		}
+		if strings.ContainsAny(p, pwAmbiguous+"!") {
+			t.Errorf("%v found removed chars", p)
+		}
+		if !strings.ContainsRune(patternClasses['C'], rune(p[0])) {
+			t.Errorf("%v is not upper consonant", p)
+		}
+		if !strings.ContainsRune(patternClasses['v'], rune(p[1])) {
+			t.Errorf("%v is not lower vowel", p)
+		}
+		if p[6] != '-' || p[9] != '-' {
+			t.Errorf("%v has not literal chars", p)
+		}
+		if !strings.ContainsRune(pwDigits, rune(p[7])) || !strings.ContainsRune(pwDigits, rune(p[8])) {
+			t.Errorf("%v has not digits", p)
+		}
+		if !strings.ContainsRune(pwSymbols, rune(p[11])) {
+			t.Errorf("%v has not symbol", p)
+		}
+	}
+	p, err := GeneratePattern("LL-99", WithRemoveChars("ABC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 5 || p[2] != '-' || strings.ContainsAny(p, "ABC") {
+		t.Errorf("unexpected password %v", p)
+	}
+	_, err = GeneratePattern("")
+	if !errors.Is(err, ErrPattern) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = GeneratePattern("L{", WithRemoveChars("ABC"))
+	if !errors.Is(err, ErrPattern) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = GeneratePattern("L", WithWords(2))
+	if !errors.Is(err, ErrMode) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
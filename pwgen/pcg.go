@@ -0,0 +1,72 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// pcg is a PCG generator with 128-bit state and DXSM output function,
+// it produces the same values as math/rand/v2 NewPCG(seed1, seed2).
+// Its stream doesn't depend on Go releases, so it's used for reproducible passwords.
+type pcg struct {
+	hi, lo uint64
+	buf    [8]byte
+	pos    int
+}
+
+// newPCG returns a new PCG generator for seeds.
+func newPCG(seed1, seed2 uint64) *pcg {
+	return &pcg{hi: seed1, lo: seed2, pos: 8}
+}
+
+// next returns the next 128-bit state, it's state * mul + inc.
+func (p *pcg) next() (uint64, uint64) {
+	const (
+		mulHi = 2549297995355413924
+		mulLo = 4865540595714422341
+		incHi = 6364136223846793005
+		incLo = 1442695040888963407
+	)
+	hi, lo := bits.Mul64(p.lo, mulLo)
+	hi += p.hi*mulLo + p.lo*mulHi
+	lo, c := bits.Add64(lo, incLo, 0)
+	hi, _ = bits.Add64(hi, incHi, c)
+	p.hi, p.lo = hi, lo
+	return hi, lo
+}
+
+// Uint64 returns a random 64-bit value.
+func (p *pcg) Uint64() uint64 {
+	const cheapMul = 0xda942042e4dd58b5
+	hi, lo := p.next()
+	hi ^= hi >> 32
+	hi *= cheapMul
+	hi ^= hi >> 48
+	hi *= lo | 1
+	return hi
+}
+
+// split returns a new generator seeded by the next two values of p.
+func (p *pcg) split() io.Reader {
+	return newPCG(p.Uint64(), p.Uint64())
+}
+
+// Read fills p by little-endian bytes of Uint64 values, it never fails.
+func (p *pcg) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		if p.pos == len(p.buf) {
+			binary.LittleEndian.PutUint64(p.buf[:], p.Uint64())
+			p.pos = 0
+		}
+		m := copy(b[n:], p.buf[p.pos:])
+		p.pos += m
+		n += m
+	}
+	return n, nil
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// minPhonemeLength is a minimal password length for the phoneme-based generator,
+	// shorter passwords are always generated by the random one.
+	minPhonemeLength = 5
+	// maxPhonemeAttempts is a maximum number of random choices of elements and extra chars
+	// to generate a pronounceable password.
+	maxPhonemeAttempts = 100000
+)
+
+// phoneme element flags
+const (
+	phConsonant = 1 << iota
+	phVowel
+	phDiphthong
+	phNotFirst
+)
+
+// phoneme generator features which have to be included to every password.
+const (
+	featureUppers = 1 << iota
+	featureDigits
+	featureSymbols
+)
+
+// phonemeElement is a pronounceable part of a password.
+type phonemeElement struct {
+	str   string
+	flags int
+}
+
+// phonemeElements is a table of elements of the original pwgen tool.
+var phonemeElements = [...]phonemeElement{
+	{"a", phVowel},
+	{"ae", phVowel | phDiphthong},
+	{"ah", phVowel | phDiphthong},
+	{"ai", phVowel | phDiphthong},
+	{"b", phConsonant},
+	{"c", phConsonant},
+	{"ch", phConsonant | phDiphthong},
+	{"d", phConsonant},
+	{"e", phVowel},
+	{"ee", phVowel | phDiphthong},
+	{"ei", phVowel | phDiphthong},
+	{"f", phConsonant},
+	{"g", phConsonant},
+	{"gh", phConsonant | phDiphthong | phNotFirst},
+	{"h", phConsonant},
+	{"i", phVowel},
+	{"ie", phVowel | phDiphthong},
+	{"j", phConsonant},
+	{"k", phConsonant},
+	{"l", phConsonant},
+	{"m", phConsonant},
+	{"n", phConsonant},
+	{"ng", phConsonant | phDiphthong | phNotFirst},
+	{"o", phVowel},
+	{"oh", phVowel | phDiphthong},
+	{"oo", phVowel | phDiphthong},
+	{"p", phConsonant},
+	{"ph", phConsonant | phDiphthong},
+	{"qu", phConsonant | phDiphthong},
+	{"r", phConsonant},
+	{"s", phConsonant},
+	{"sh", phConsonant | phDiphthong},
+	{"t", phConsonant},
+	{"th", phConsonant | phDiphthong},
+	{"u", phVowel},
+	{"v", phConsonant},
+	{"w", phConsonant},
+	{"x", phConsonant},
+	{"y", phConsonant},
+	{"z", phConsonant},
+}
+
+// usePhonemes returns true if the phoneme-based generator should be used.
+func (pg *PwGen) usePhonemes() bool {
+	return pg.phoneme && pg.pwLength >= minPhonemeLength
+}
+
+// phonemeFeatures returns features required for every phoneme-based password.
+func (pg *PwGen) phonemeFeatures() int {
+	var features int
+	if !pg.noCapitalize && pg.pwLength > 2 {
+		features |= featureUppers
+	}
+	if !pg.noNumerals && pg.numerals && pg.pwLength > 1 {
+		features |= featureDigits
+	}
+	if pg.symbols {
+		features |= featureSymbols
+	}
+	return features
+}
+
+// phonemeType returns a random type of the next phoneme element.
+func (pg *PwGen) phonemeType() int {
+	if pg.intn(2) == 0 {
+		return phConsonant
+	}
+	return phVowel
+}
+
+// phonemeExtra returns a random char from alphabet skipping ambiguous ones if it's needed.
+// Every choice decreases attempts, it returns false if they are over.
+func (pg *PwGen) phonemeExtra(alphabet string, attempts *int) (byte, bool) {
+	for *attempts > 0 {
+		*attempts--
+		c := pg.choiceFromString(alphabet)
+		if !pg.ambiguous || strings.IndexByte(pwAmbiguous, c) < 0 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// generatePhonemes returns a new pronounceable password.
+// It returns *RandomError if the random source gives only rejected choices after many attempts.
+func (pg *PwGen) generatePhonemes() ([]byte, error) {
+	password := make([]byte, pg.pwLength)
+	features := pg.phonemeFeatures()
+	attempts := maxPhonemeAttempts
+	for !pg.fillPhonemes(password, features, &attempts) {
+		if attempts <= 0 {
+			wipe(password)
+			err := fmt.Errorf("%w: no pronounceable password is found after %d attempts", ErrRejected, maxPhonemeAttempts)
+			return nil, &RandomError{Err: err}
+		}
+	}
+	return password, nil
+}
+
+// fillPhonemes fills the password by phoneme elements,
+// it returns false if some of required features were not included or attempts are over.
+// Every random choice of an element or an extra char decreases attempts.
+func (pg *PwGen) fillPhonemes(password []byte, features int, attempts *int) bool {
+	var (
+		c, prev  int
+		ok       bool
+		size     = len(password)
+		first    = true
+		required = features
+		shouldBe = pg.phonemeType()
+	)
+	for c < size {
+		if *attempts <= 0 {
+			return false
+		}
+		*attempts--
+		e := &phonemeElements[pg.intn(len(phonemeElements))]
+		switch {
+		case e.flags&shouldBe == 0:
+			continue
+		case first && (e.flags&phNotFirst != 0):
+			continue
+		case (prev&phVowel != 0) && (e.flags&phVowel != 0) && (e.flags&phDiphthong != 0):
+			// don't allow vowel followed a vowel/diphthong pair
+			continue
+		case len(e.str) > size-c:
+			continue
+		}
+		n := copy(password[c:], e.str)
+		upper := (features&featureUppers != 0) && (first || e.flags&phConsonant != 0) && (pg.intn(10) < 2)
+		if upper {
+			password[c] -= 'a' - 'A'
+		}
+		if pg.ambiguous && strings.ContainsAny(string(password[c:c+n]), pwAmbiguous) {
+			continue
+		}
+		if upper {
+			required &^= featureUppers
+		}
+		c += n
+		if c >= size {
+			break
+		}
+		if (features&featureDigits != 0) && !first && (pg.intn(10) < 3) {
+			if password[c], ok = pg.phonemeExtra(pwDigits, attempts); !ok {
+				return false
+			}
+			c++
+			required &^= featureDigits
+			// start a new pronounceable part
+			first, prev = true, 0
+			shouldBe = pg.phonemeType()
+			continue
+		}
+		if (features&featureSymbols != 0) && !first && (pg.intn(10) < 2) {
+			if password[c], ok = pg.phonemeExtra(pwSymbols, attempts); !ok {
+				return false
+			}
+			c++
+			required &^= featureSymbols
+		}
+		// choose a type of the next element
+		switch {
+		case shouldBe == phConsonant:
+			shouldBe = phVowel
+		case (prev&phVowel != 0) || (e.flags&phDiphthong != 0) || (pg.intn(10) > 3):
+			shouldBe = phConsonant
+		default:
+			shouldBe = phVowel
+		}
+		prev = e.flags
+		first = false
+	}
+	return required == 0
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhonemes(t *testing.T) {
+	pwLength := 12
+	pg, err := New(
+		pwLength, 10000, "", "",
+		false, true, false,
+		false, true, true, false, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pg.usePhonemes() {
+		t.Fatal("phoneme-based generator is not used")
+	}
+	digits := []byte(pwDigits)
+	uppers := []byte(pwUppers)
+	symbols := []byte(pwSymbols)
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i] < symbols[j] })
+	ambiguous := []byte(pwAmbiguous)
+	sort.Slice(ambiguous, func(i, j int) bool { return ambiguous[i] < ambiguous[j] })
+
+	for p := range pg.Passwords() {
+		if l := len(p); l != pwLength {
+			t.Errorf("%v failed len=%v", p, l)
+		}
+		if !any(p, digits) {
+			t.Errorf("%v no digits", p)
+		}
+		if !any(p, uppers) {
+			t.Errorf("%v no uppers", p)
+		}
+		if !any(p, symbols) {
+			t.Errorf("%v no symbols", p)
+		}
+		if any(p, ambiguous) {
+			t.Errorf("%v found ambiguous", p)
+		}
+	}
+}
+
+func TestPhonemesElements(t *testing.T) {
+	pg, err := New(
+		10, 1000, "", "",
+		true, false, false,
+		true, false, false, false, false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range pg.Passwords() {
+		// only lower letters, each password is a sequence of table elements
+		s := p
+		for s != "" {
+			found := false
+			for _, e := range phonemeElements {
+				if strings.HasPrefix(s, e.str) {
+					s = s[len(e.str):]
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("%v is not pronounceable", p)
+				break
+			}
+		}
+	}
+}
+
+func TestPhonemesDisabled(t *testing.T) {
+	values := []struct {
+		pwLength             int
+		removeChars          string
+		noVowels, secure, ok bool
+	}{
+		{8, "", false, false, true},
+		{minPhonemeLength, "", false, false, true},
+		{minPhonemeLength - 1, "", false, false, false},
+		{8, "abc", false, false, false},
+		{8, "", true, false, false},
+		{8, "", false, true, false},
+	}
+	for i, v := range values {
+		pg, err := New(
+			v.pwLength, 1, v.removeChars, "",
+			false, true, false,
+			false, false, false, v.noVowels, v.secure,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok := pg.usePhonemes(); ok != v.ok {
+			t.Errorf("[%v] unexpected phonemes usage %v", i, ok)
+		}
+	}
+}
+
+func TestPhonemesRejected(t *testing.T) {
+	pg, err := NewWithOptions(WithReader(constReader(0)), WithNumber(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pg.usePhonemes() {
+		t.Fatal("phoneme-based generator is not used")
+	}
+	_, err = pg.TryGenerate()
+	var e *RandomError
+	if !errors.As(err, &e) || !errors.Is(err, ErrRejected) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var b strings.Builder
+	if err = pg.PrintContext(ctx, &b); !errors.Is(err, ErrRejected) {
+		t.Errorf("unexpected print error: %v", err)
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// chars classes of random passwords
+const (
+	classLowers = iota
+	classUppers
+	classDigits
+	classSymbols
+	numClasses
+
+	classAny = -1 // any char of the alphabet
+)
+
+var (
+	// classAlphabets contains all chars of the classes.
+	classAlphabets = [numClasses]string{pwLowers, pwUppers, pwDigits, pwSymbols}
+	// classNames contains human readable names of the classes.
+	classNames = [numClasses]string{"lowers", "uppers", "digits", "symbols"}
+
+	// ErrViolation is an error of a password which doesn't satisfy the policy.
+	ErrViolation = errors.New("password policy violation")
+)
+
+// Policy is a set of constraints for every random password.
+// Zero maximum values mean no limits.
+type Policy struct {
+	MinLength      int    `json:"min_length,omitempty"`
+	MaxLength      int    `json:"max_length,omitempty"`
+	MinLowers      int    `json:"min_lowers,omitempty"`
+	MaxLowers      int    `json:"max_lowers,omitempty"`
+	MinUppers      int    `json:"min_uppers,omitempty"`
+	MaxUppers      int    `json:"max_uppers,omitempty"`
+	MinDigits      int    `json:"min_digits,omitempty"`
+	MaxDigits      int    `json:"max_digits,omitempty"`
+	MinSymbols     int    `json:"min_symbols,omitempty"`
+	MaxSymbols     int    `json:"max_symbols,omitempty"`
+	MaxConsecutive int    `json:"max_consecutive,omitempty"` // maximum number of consecutive identical chars
+	ForbiddenFirst string `json:"forbidden_first,omitempty"` // chars which can't be used as the first one
+}
+
+// charClass returns a class of the char.
+func charClass(c byte) int {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return classLowers
+	case c >= 'A' && c <= 'Z':
+		return classUppers
+	case c >= '0' && c <= '9':
+		return classDigits
+	}
+	return classSymbols
+}
+
+// limits returns minimum and maximum numbers of chars for every class.
+func (p *Policy) limits() (min, max [numClasses]int) {
+	min = [numClasses]int{p.MinLowers, p.MinUppers, p.MinDigits, p.MinSymbols}
+	max = [numClasses]int{p.MaxLowers, p.MaxUppers, p.MaxDigits, p.MaxSymbols}
+	return min, max
+}
+
+// strict returns true if chars have to be chosen with respect to their positions and classes limits.
+func (p *Policy) strict() bool {
+	_, max := p.limits()
+	return p.MaxConsecutive > 0 || p.ForbiddenFirst != "" || max != [numClasses]int{}
+}
+
+// validate returns an error if passwords of the length can't satisfy the policy
+// using chars of the classes.
+func (p *Policy) validate(length int, classes *[numClasses][]byte) error {
+	var minSum, capacity int
+	unlimited, first := false, false
+	min, max := p.limits()
+	switch {
+	case p.MaxConsecutive < 0:
+		return fmt.Errorf("%w: negative max consecutive", ErrPolicy)
+	case length < p.MinLength:
+		return fmt.Errorf("%w: length %d is less than %d", ErrPolicy, length, p.MinLength)
+	case p.MaxLength > 0 && length > p.MaxLength:
+		return fmt.Errorf("%w: length %d is greater than %d", ErrPolicy, length, p.MaxLength)
+	}
+	for k := 0; k < numClasses; k++ {
+		switch {
+		case min[k] < 0 || max[k] < 0:
+			return fmt.Errorf("%w: negative %s limit", ErrPolicy, classNames[k])
+		case max[k] > 0 && max[k] < min[k]:
+			return fmt.Errorf("%w: max %s is less than min", ErrPolicy, classNames[k])
+		case min[k] > 0 && len(classes[k]) == 0:
+			return fmt.Errorf("%w: no chars for %s", ErrPolicy, classNames[k])
+		}
+		minSum += min[k]
+		if len(classes[k]) > 0 {
+			if max[k] == 0 {
+				unlimited = true
+			}
+			capacity += max[k]
+		}
+	}
+	switch {
+	case minSum > length:
+		return fmt.Errorf("%w: required %d chars for length %d", ErrPolicy, minSum, length)
+	case !unlimited && capacity < length:
+		return fmt.Errorf("%w: allowed only %d chars for length %d", ErrPolicy, capacity, length)
+	}
+	for k := 0; k < numClasses; k++ {
+		// the first char takes a free slot or a required one of its class
+		if (minSum < length || min[k] > 0) && p.allowedFirst(classes[k]) {
+			first = true
+		}
+	}
+	if !first {
+		return fmt.Errorf("%w: no allowed chars for the first one", ErrPolicy)
+	}
+	if p.MaxConsecutive == 0 {
+		return nil
+	}
+	for k := 0; k < numClasses; k++ {
+		if len(classes[k]) != 1 {
+			// different chars of the class can alternate
+			continue
+		}
+		// the only char of the class fills all slots which other classes can't take
+		others := 0
+		for j := 0; j < numClasses; j++ {
+			switch {
+			case j == k || len(classes[j]) == 0:
+			case max[j] == 0:
+				others = length
+			default:
+				others += max[j]
+			}
+		}
+		n := min[k]
+		if rest := length - others; rest > n {
+			n = rest
+		}
+		// n identical chars are split by other ones into at most length-n+1 runs
+		if n > p.MaxConsecutive*(length-n+1) {
+			return fmt.Errorf("%w: too many consecutive %s", ErrPolicy, classNames[k])
+		}
+	}
+	return nil
+}
+
+// allowedFirst returns true if any of the chars can be the first one.
+func (p *Policy) allowedFirst(chars []byte) bool {
+	for _, c := range chars {
+		if strings.IndexByte(p.ForbiddenFirst, c) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns ErrViolation if the password doesn't satisfy the policy.
+func (p *Policy) Check(password string) error {
+	if violations := p.violations(password); len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrViolation, violations[0])
+	}
+	return nil
+}
+
+// violations returns descriptions of all policy violations of the password,
+// they don't contain its chars.
+func (p *Policy) violations(password string) []string {
+	var (
+		result      []string
+		counts      [numClasses]int
+		run         int
+		consecutive bool
+	)
+	if n := len(password); n < p.MinLength || (p.MaxLength > 0 && n > p.MaxLength) {
+		result = append(result, fmt.Sprintf("length %d is out of range", n))
+	}
+	if password != "" && strings.IndexByte(p.ForbiddenFirst, password[0]) >= 0 {
+		result = append(result, "forbidden first char")
+	}
+	for i := 0; i < len(password); i++ {
+		counts[charClass(password[i])]++
+		if i > 0 && password[i] == password[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if p.MaxConsecutive > 0 && run > p.MaxConsecutive && !consecutive {
+			consecutive = true
+			result = append(result, fmt.Sprintf("more than %d consecutive chars at %d", p.MaxConsecutive, i-p.MaxConsecutive))
+		}
+	}
+	min, max := p.limits()
+	for k := 0; k < numClasses; k++ {
+		if counts[k] < min[k] {
+			result = append(result, fmt.Sprintf("less than %d %s", min[k], classNames[k]))
+		}
+		if max[k] > 0 && counts[k] > max[k] {
+			result = append(result, fmt.Sprintf("more than %d %s", max[k], classNames[k]))
+		}
+	}
+	return result
+}
+
+// fillRandom fills the password by random chars satisfying the policy,
+// it returns false if the chosen chars can't satisfy it and a new attempt is needed.
+func (pg *PwGen) fillRandom(password []byte) bool {
+	var counts [numClasses]int
+	n := len(password)
+	min, _ := pg.policy.limits()
+	// positions of required classes chars
+	slots := make([]int, n)
+	i := 0
+	for k := 0; k < numClasses; k++ {
+		for j := 0; j < min[k] && i < n; j++ {
+			slots[i] = k
+			counts[k]++
+			i++
+		}
+	}
+	if i > 0 {
+		for j := i; j < n; j++ {
+			slots[j] = classAny
+		}
+		pg.shuffle(n, func(i, j int) {
+			slots[i], slots[j] = slots[j], slots[i]
+		})
+	} else {
+		for j := range slots {
+			slots[j] = classAny
+		}
+	}
+	strict := pg.policy.strict()
+	for i, k := range slots {
+		chars := pg.chars
+		if k != classAny {
+			chars = pg.classes[k]
+		}
+		if strict {
+			chars = pg.allowed(chars, password[:i], k == classAny, &counts)
+			if len(chars) == 0 {
+				return false
+			}
+		}
+		c := pg.choice(chars)
+		if k == classAny {
+			counts[charClass(c)]++
+		}
+		password[i] = c
+	}
+	return true
+}
+
+// allowed returns chars which can be appended to the prefix according the policy.
+func (pg *PwGen) allowed(chars, prefix []byte, free bool, counts *[numClasses]int) []byte {
+	var run int
+	_, max := pg.policy.limits()
+	n := len(prefix)
+	if n > 0 {
+		for run = 1; run < n && prefix[n-run-1] == prefix[n-1]; run++ {
+		}
+	}
+	result := make([]byte, 0, len(chars))
+	for _, c := range chars {
+		k := charClass(c)
+		switch {
+		case n == 0 && strings.IndexByte(pg.policy.ForbiddenFirst, c) >= 0:
+			continue
+		case pg.policy.MaxConsecutive > 0 && run >= pg.policy.MaxConsecutive && c == prefix[n-1]:
+			continue
+		case free && max[k] > 0 && counts[k] >= max[k]:
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// splitPolicy splits the policy by commas, "\\," is a comma and "\\\\" is a backslash of values,
+// other backslashes are kept as is.
+func splitPolicy(s string) []string {
+	var (
+		items []string
+		b     strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == ',' || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		case c == ',':
+			items = append(items, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(items, b.String())
+}
+
+// ParsePolicy returns a policy from comma separated "key=value" pairs, ie:
+// "min-uppers=1,min-digits=2,max-consecutive=2,forbidden-first=0123456789".
+// Keys are min-length, max-length, min-lowers, max-lowers, min-uppers, max-uppers, min-digits, max-digits,
+// min-symbols, max-symbols, max-consecutive and forbidden-first.
+// Commas and backslashes of forbidden-first chars are escaped by a backslash: "forbidden-first=-\\,\\\\".
+func ParsePolicy(s string) (*Policy, error) {
+	p := &Policy{}
+	values := map[string]*int{
+		"min-length":      &p.MinLength,
+		"max-length":      &p.MaxLength,
+		"min-lowers":      &p.MinLowers,
+		"max-lowers":      &p.MaxLowers,
+		"min-uppers":      &p.MinUppers,
+		"max-uppers":      &p.MaxUppers,
+		"min-digits":      &p.MinDigits,
+		"max-digits":      &p.MaxDigits,
+		"min-symbols":     &p.MinSymbols,
+		"max-symbols":     &p.MaxSymbols,
+		"max-consecutive": &p.MaxConsecutive,
+	}
+	for _, item := range splitPolicy(s) {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("%w: no value of %q", ErrPolicy, item)
+		}
+		key, value := item[:i], item[i+1:]
+		if key == "forbidden-first" {
+			p.ForbiddenFirst = value
+			continue
+		}
+		v, ok := values[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown key %q", ErrPolicy, key)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid value of %q", ErrPolicy, key)
+		}
+		*v = n
+	}
+	return p, nil
+}
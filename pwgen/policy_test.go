@@ -0,0 +1,181 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	values := []struct {
+		length  int
+		symbols bool
+		policy  Policy
+	}{
+		{8, false, Policy{MinUppers: 1}},
+		{12, true, Policy{MinLowers: 2, MinUppers: 2, MinDigits: 2, MinSymbols: 2}},
+		{16, false, Policy{MaxConsecutive: 1, ForbiddenFirst: pwDigits}},
+		{10, true, Policy{MaxSymbols: 1, MaxDigits: 2, MaxUppers: 3, MaxConsecutive: 2}},
+		{6, false, Policy{MinDigits: 6, MaxConsecutive: 1}},
+		{4, false, Policy{MaxLowers: 1, MaxUppers: 1, MaxDigits: 2, ForbiddenFirst: pwLowers + pwUppers}},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(
+			WithLength(v.length), WithNumber(2000), WithSymbols(v.symbols), WithPolicy(v.policy),
+		)
+		if err != nil {
+			t.Fatalf("[%v] unexpected error: %v", i, err)
+		}
+		if pg.usePhonemes() {
+			t.Errorf("[%v] phoneme-based generator is used", i)
+		}
+		for p := range pg.Passwords() {
+			if l := len(p); l != v.length {
+				t.Errorf("[%v] %v failed len=%v", i, p, l)
+			}
+			if err = v.policy.Check(p); err != nil {
+				t.Errorf("[%v] %v: %v", i, p, err)
+			}
+		}
+	}
+}
+
+func TestPolicyFail(t *testing.T) {
+	values := []struct {
+		length int
+		opts   []Option
+		policy Policy
+	}{
+		{8, nil, Policy{MinUppers: -1}},
+		{8, nil, Policy{MaxConsecutive: -1}},
+		{8, nil, Policy{MinDigits: 3, MaxDigits: 2}},
+		{8, nil, Policy{MinSymbols: 1}},
+		{8, []Option{WithNoCapitalize(true)}, Policy{MinUppers: 1}},
+		{8, nil, Policy{MinLowers: 3, MinUppers: 3, MinDigits: 3}},
+		{8, nil, Policy{MaxLowers: 2, MaxUppers: 2, MaxDigits: 2}},
+		{8, []Option{WithNoNumerals(true)}, Policy{ForbiddenFirst: pwLowers + pwUppers}},
+		{4, []Option{WithRemoveChars("012345678")}, Policy{MinDigits: 3, MaxConsecutive: 1}},
+		{8, []Option{WithWords(3)}, Policy{MinUppers: 1}},
+		{8, []Option{WithPattern("LLL")}, Policy{MinUppers: 1}},
+		{8, nil, Policy{MinLength: 10}},
+		{8, nil, Policy{MaxLength: 6}},
+		{4, []Option{WithSecure(true)}, Policy{MinDigits: 4, ForbiddenFirst: pwDigits}},
+		{6, nil, Policy{MinLowers: 3, MinDigits: 3, ForbiddenFirst: pwLowers + pwDigits}},
+		{3, []Option{WithNoNumerals(true), WithNoCapitalize(true), WithRemoveChars(pwLowers[1:])}, Policy{MaxConsecutive: 1}},
+		{5, []Option{WithNoCapitalize(true), WithRemoveChars(pwLowers[1:])}, Policy{MaxDigits: 1, MaxConsecutive: 1}},
+		// only "a", "b" and "Z" chars: "Z" must be the first one or consecutive
+		{
+			3, []Option{WithNoNumerals(true), WithRemoveChars(pwLowers[2:] + pwUppers[:25])},
+			Policy{MaxLowers: 1, MaxConsecutive: 1, ForbiddenFirst: "Z"},
+		},
+	}
+	for i, v := range values {
+		opts := append(v.opts, WithLength(v.length), WithPolicy(v.policy))
+		_, err := NewWithOptions(opts...)
+		if !errors.Is(err, ErrPolicy) {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestPolicyAttempts(t *testing.T) {
+	pg, err := NewWithOptions(WithPolicy(Policy{MinUppers: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg.policy.ForbiddenFirst = string(pg.chars)
+	if _, err = pg.TryGenerate(); !errors.Is(err, ErrPolicy) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		MinUppers:      1,
+		MaxUppers:      2,
+		MinDigits:      1,
+		MaxConsecutive: 2,
+		ForbiddenFirst: "!",
+		MaxLength:      30,
+	}
+	values := map[string]bool{
+		"aB1":                             true,
+		"aaB1":                            true,
+		"aaaB1":                           false,
+		"!aB1":                            false,
+		"ab1":                             false,
+		"aBCD1":                           false,
+		"aBc":                             false,
+		"a!BC1":                           true,
+		"11aB1x":                          true,
+		"aB1xxyyzz1aB1xxyyzz1aB1xxyyzz1a": false,
+	}
+	for p, ok := range values {
+		err := policy.Check(p)
+		if ok && err != nil {
+			t.Errorf("%v unexpected error: %v", p, err)
+		}
+		if !ok && !errors.Is(err, ErrViolation) {
+			t.Errorf("%v no expected violation: %v", p, err)
+		}
+	}
+}
+
+func TestLegacyPolicy(t *testing.T) {
+	values := []struct {
+		length           int
+		symbols          bool
+		digits, specials int
+	}{
+		{1, false, 0, 0},
+		{2, false, 1, 0},
+		{2, true, 0, 1},
+		{3, true, 1, 1},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(WithLength(v.length), WithSymbols(v.symbols))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pg.policy.MinDigits != v.digits || pg.policy.MinSymbols != v.specials {
+			t.Errorf("[%v] unexpected policy %+v", i, pg.policy)
+		}
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("min-length=8,max-length=64,min-lowers=1, max-lowers=9,min-uppers=2,max-uppers=8,min-digits=3,max-digits=7," +
+		"min-symbols=4,max-symbols=6,max-consecutive=5,forbidden-first=0@,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Policy{8, 64, 1, 9, 2, 8, 3, 7, 4, 6, 5, "0@"}
+	if *p != expected {
+		t.Errorf("unexpected policy %+v", p)
+	}
+	for _, s := range []string{"min-lowers", "min-lowers=a", "unknown=1", "forbidden-first=-,@"} {
+		if _, err = ParsePolicy(s); !errors.Is(err, ErrPolicy) {
+			t.Errorf("%v unexpected error: %v", s, err)
+		}
+	}
+	// escaped commas and backslashes, other backslashes are kept
+	values := map[string]string{
+		`forbidden-first=-\,`:               "-,",
+		`forbidden-first=\,\\,min-digits=1`: `,\`,
+		`forbidden-first=\a`:                `\a`,
+		`forbidden-first=\`:                 `\`,
+	}
+	for s, expected := range values {
+		p, err = ParsePolicy(s)
+		if err != nil {
+			t.Errorf("%v unexpected error: %v", s, err)
+			continue
+		}
+		if p.ForbiddenFirst != expected {
+			t.Errorf("%v unexpected forbidden first chars %q", s, p.ForbiddenFirst)
+		}
+	}
+}
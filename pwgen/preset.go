@@ -0,0 +1,167 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ErrPreset is an error of an invalid preset.
+var ErrPreset = errors.New("invalid preset")
+
+// Preset is a named set of passwords generation parameters for some target system.
+type Preset struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Length      int    `json:"length"`
+	Symbols     bool   `json:"symbols,omitempty"`
+	Secure      bool   `json:"secure,omitempty"`
+	RemoveChars string `json:"remove_chars,omitempty"`
+	Policy      Policy `json:"policy"`
+}
+
+// Presets is a set of presets by their names.
+type Presets map[string]Preset
+
+// builtinPresets contains presets for common target systems.
+var builtinPresets = []Preset{
+	{
+		Name:        "ad",
+		Description: "Active Directory account, complexity requirements",
+		Length:      16,
+		Symbols:     true,
+		Secure:      true,
+		Policy: Policy{
+			MinLength: 8, MaxLength: 256,
+			MinLowers: 1, MinUppers: 1, MinDigits: 1, MinSymbols: 1,
+		},
+	},
+	{
+		Name:        "aws-iam",
+		Description: "AWS IAM user console password",
+		Length:      20,
+		Symbols:     true,
+		Secure:      true,
+		RemoveChars: "\",./:;<>?\\`~",
+		Policy: Policy{
+			MinLength: 8, MaxLength: 128,
+			MinLowers: 1, MinUppers: 1, MinDigits: 1, MinSymbols: 1,
+		},
+	},
+	{
+		Name:        "postgresql",
+		Description: "PostgreSQL role, safe for SQL literals and connection URLs",
+		Length:      32,
+		Symbols:     true,
+		Secure:      true,
+		RemoveChars: "\"'\\`@/:%?#&",
+		Policy: Policy{
+			MinLength: 8, MaxLength: 128,
+			MinLowers: 1, MinUppers: 1, MinDigits: 1,
+		},
+	},
+	{
+		Name:        "mysql",
+		Description: "MySQL user, RDS master password limits",
+		Length:      32,
+		Symbols:     true,
+		Secure:      true,
+		RemoveChars: "\"'\\`@/",
+		Policy: Policy{
+			MinLength: 8, MaxLength: 41,
+			MinLowers: 1, MinUppers: 1, MinDigits: 1,
+		},
+	},
+	{
+		Name:        "oracle",
+		Description: "Oracle user, unquoted identifier rules",
+		Length:      24,
+		Symbols:     true,
+		Secure:      true,
+		RemoveChars: "!\"%&'()*+,-./:;<=>?@[\\]^`{|}~",
+		Policy: Policy{
+			MinLength: 8, MaxLength: 30,
+			MinLowers: 1, MinUppers: 1, MinDigits: 1,
+			ForbiddenFirst: pwDigits + pwSymbols,
+		},
+	},
+}
+
+// DefaultPresets returns built-in presets for Active Directory, AWS IAM,
+// PostgreSQL, MySQL and Oracle.
+func DefaultPresets() Presets {
+	presets := make(Presets, len(builtinPresets))
+	for _, p := range builtinPresets {
+		presets[p.Name] = p
+	}
+	return presets
+}
+
+// Load reads a JSON array of presets from r and adds them,
+// existing presets are replaced by ones with the same names.
+func (ps Presets) Load(r io.Reader) error {
+	var items []Preset
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return fmt.Errorf("%w: %v", ErrPreset, err)
+	}
+	for i, p := range items {
+		if p.Name == "" {
+			return fmt.Errorf("%w: no name of item %d", ErrPreset, i)
+		}
+		if p.Length < 1 {
+			return fmt.Errorf("%w: no length of %q", ErrPreset, p.Name)
+		}
+		ps[p.Name] = p
+	}
+	return nil
+}
+
+// LoadFile reads a JSON array of presets from the file and adds them.
+func (ps Presets) LoadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	err = ps.Load(f)
+	if err != nil {
+		_ = f.Close() // ignore error
+		return err
+	}
+	return f.Close()
+}
+
+// Names returns sorted names of presets.
+func (ps Presets) Names() []string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply changes the configuration by the preset.
+// It sets the length and the policy if the last one is not set yet,
+// enables symbols and secure mode if they are required and adds removed chars.
+func (p *Preset) Apply(cfg *Config) {
+	cfg.Length = p.Length
+	cfg.Symbols = cfg.Symbols || p.Symbols
+	cfg.Secure = cfg.Secure || p.Secure
+	cfg.RemoveChars += p.RemoveChars
+	if cfg.Policy == nil {
+		policy := p.Policy
+		cfg.Policy = &policy
+	}
+}
+
+// WithPreset changes the configuration by the preset.
+func WithPreset(p Preset) Option {
+	return func(c *Config) { p.Apply(c) }
+}
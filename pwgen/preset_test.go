@@ -0,0 +1,119 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPresets(t *testing.T) {
+	presets := DefaultPresets()
+	names := presets.Names()
+	if s := strings.Join(names, ","); s != "ad,aws-iam,mysql,oracle,postgresql" {
+		t.Errorf("unexpected names: %v", s)
+	}
+	for _, name := range names {
+		preset := presets[name]
+		pg, err := NewWithOptions(WithNumber(1000), WithPreset(preset))
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if !pg.secure || !pg.symbols {
+			t.Errorf("%v: unexpected flags", name)
+		}
+		for p := range pg.Passwords() {
+			if l := len(p); l != preset.Length {
+				t.Errorf("%v: %v failed len=%v", name, p, l)
+			}
+			if strings.ContainsAny(p, preset.RemoveChars) {
+				t.Errorf("%v: %v found removed chars", name, p)
+			}
+			if err = preset.Policy.Check(p); err != nil {
+				t.Errorf("%v: %v", name, err)
+			}
+		}
+		// length limits
+		_, err = NewWithOptions(WithPreset(preset), WithLength(preset.Policy.MaxLength+1))
+		if !errors.Is(err, ErrPolicy) {
+			t.Errorf("%v: unexpected error: %v", name, err)
+		}
+	}
+}
+
+func TestPresetApply(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RemoveChars = "a"
+	cfg.Policy = &Policy{MinDigits: 3}
+	preset := Preset{Name: "test", Length: 12, Symbols: true, RemoveChars: "b", Policy: Policy{MinUppers: 1}}
+	preset.Apply(cfg)
+	if cfg.Length != 12 || !cfg.Symbols || cfg.Secure || cfg.RemoveChars != "ab" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Policy.MinDigits != 3 || cfg.Policy.MinUppers != 0 {
+		t.Errorf("unexpected policy: %+v", cfg.Policy)
+	}
+}
+
+func TestPresetsLoad(t *testing.T) {
+	data := `[
+		{"name": "app", "length": 20, "symbols": true, "remove_chars": "'", "policy": {"min_digits": 2}},
+		{"name": "ad", "length": 10, "secure": true, "policy": {"max_length": 12, "forbidden_first": "0"}}
+	]`
+	presets := DefaultPresets()
+	if err := presets.Load(strings.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	p, ok := presets["app"]
+	if !ok {
+		t.Fatal("not found loaded preset")
+	}
+	if p.Length != 20 || !p.Symbols || p.RemoveChars != "'" || p.Policy.MinDigits != 2 {
+		t.Errorf("unexpected preset: %+v", p)
+	}
+	p = presets["ad"]
+	if p.Length != 10 || !p.Secure || p.Policy.MaxLength != 12 || p.Policy.ForbiddenFirst != "0" {
+		t.Errorf("unexpected preset: %+v", p)
+	}
+	if n := len(presets); n != 6 {
+		t.Errorf("unexpected number of presets: %v", n)
+	}
+	values := []string{
+		`{"name": "app"}`,
+		`[{"name": "", "length": 10}]`,
+		`[{"name": "app", "length": 0}]`,
+	}
+	for i, v := range values {
+		if err := presets.Load(strings.NewReader(v)); !errors.Is(err, ErrPreset) {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestPresetsLoadFile(t *testing.T) {
+	fullName := path.Join(os.TempDir(), "pwgen_presets_test.tmp")
+	err := os.WriteFile(fullName, []byte(`[{"name": "app", "length": 20}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(fullName); err != nil {
+			t.Error(err)
+		}
+	}()
+	presets := Presets{}
+	if err = presets.LoadFile(fullName); err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(presets.Names(), ","); s != "app" {
+		t.Errorf("unexpected names: %v", s)
+	}
+	if err = presets.LoadFile("/root/bad_123"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
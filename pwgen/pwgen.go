@@ -6,54 +6,72 @@
 package pwgen
 
 import (
-	crand "crypto/rand"
-	"crypto/sha1"
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"math/rand"
-	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const (
 	defaultPwLength = 8   // default password length
 	defaultNumPw    = 160 // default number of generated passwords
-	screenWidth     = 80  // screen width for output by columns
+	screenWidth     = 80  // default screen width for output by columns
+
+	// maxRejectedAttempts is a maximum number of generated passwords to get not rejected one.
+	maxRejectedAttempts = 100
+	// maxPolicyAttempts is a maximum number of attempts to choose chars of a random password satisfying the policy.
+	maxPolicyAttempts = 10000
 
 	// passwords alphabets
 	pwDigits    = "0123456789"
 	pwLowers    = "abcdefghijklmnopqrstuvwxyz"
 	pwUppers    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	pwSymbols   = "!\"#$%&\\'()*+,-./:;<=>?@[\\]^_`{|}~"
+	pwSymbols   = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
 	pwAmbiguous = "B8G6I1l0OQDS5Z2"
 	pwVowels    = "01aeiouyAEIOUY"
 )
 
 // PwGen is main struct for passwords generation by required rules.
+// It isn't safe for concurrent use, every goroutine should use its own Fork.
 type PwGen struct {
 	pwLength, numPw               int
 	noNumerals, numerals, oneLine bool
+	format                        string
+	metadata                      bool
+	hash                          string
+	workers                       int
+	unordered                     bool
+	screenWidth                   int
+	onePerLine, columns           bool
 	noCapitalize, ambiguous       bool
-	symbols, secure               bool
+	symbols, secure, phoneme      bool
 	random                        *rand.Rand
+	source                        rand.Source
+	reader                        io.Reader
+	breaches                      *BreachList
+	blocklist                     *Blocklist
 	chars                         []byte
+	words                         int
+	separator                     string
+	wordList                      []string
+	pattern                       [][]byte
+	policy                        Policy
+	classes                       [numClasses][]byte
 }
 
 // CryptoRandSource represents a source of uniformly-distributed random int64 values in the range [0, 1<<63).
+// It uses the shared buffered CSPRNG seeded from crypto/rand and panics if it fails,
+// WithReader option allows to handle errors of random sources.
 type CryptoRandSource struct{}
 
 // Int63 returns a non-negative random 63-bit integer as an int64 from CryptoRandSource.
 func (CryptoRandSource) Int63() int64 {
-	var b [8]byte
-	_, err := crand.Read(b[:])
-	if err != nil {
-		panic(err) // fail - can't continue
-	}
-	return int64(binary.LittleEndian.Uint64(b[:]) & (1<<63 - 1))
+	return secureRandom.Int63()
 }
 
 // Seed is fake CryptoRandSource Seed implementation for Source interface.
@@ -107,150 +125,384 @@ func randomSource(secure bool, seed int64) rand.Source {
 }
 
 // New returns new password generation structure.
+// It's a compatibility wrapper, use NewWithConfig or NewWithOptions instead.
 func New(pwLength, numPw int, removeChars, sha1File string,
 	noNumerals, numerals, oneLine, noCapitalize, ambiguous, symbols, noVowels, secure bool) (*PwGen, error) {
+	return NewWithConfig(&Config{
+		Length:       pwLength,
+		Number:       numPw,
+		RemoveChars:  removeChars,
+		SHA1File:     sha1File,
+		NoNumerals:   noNumerals,
+		Numerals:     numerals,
+		OneLine:      oneLine,
+		NoCapitalize: noCapitalize,
+		Ambiguous:    ambiguous,
+		Symbols:      symbols,
+		NoVowels:     noVowels,
+		Secure:       secure,
+	})
+}
 
-	var seed int64
-	if pwLength < 1 {
-		return nil, errors.New("password length should be greater than 0")
+// NewWithOptions returns new password generation structure
+// for the default configuration changed by options.
+func NewWithOptions(opts ...Option) (*PwGen, error) {
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
 	}
-	if numPw < 1 {
-		return nil, errors.New("password length should be greater than 0")
+	return NewWithConfig(cfg)
+}
+
+// NewWithConfig returns new password generation structure for the configuration.
+func NewWithConfig(cfg *Config) (*PwGen, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if cfg.Reader != nil {
+		// custom readers are shared by forks
+		reader = &lockedReader{r: cfg.Reader}
 	}
-	if sha1File != "" {
-		f, err := os.Open(sha1File)
+	switch {
+	case cfg.SHA1File != "":
+		key, err := sha1Key(cfg.SHA1File)
 		if err != nil {
-			return nil, err
+			return nil, &ConfigError{Field: "SHA1File", Err: err}
+		}
+		reader = newDRBG(key)
+	case cfg.Site != "":
+		key := SiteKey(cfg.Master, cfg.Site, cfg.Login, cfg.Counter)
+		reader = newDRBG(key)
+		for i := range key {
+			key[i] = 0
 		}
-		h := sha1.New()
-		_, err = io.Copy(h, f)
+	case cfg.Seed != 0:
+		reader = newPCG(uint64(cfg.Seed), 0)
+	}
+	var breaches *BreachList
+	if cfg.BreachList != "" {
+		var err error
+		breaches, err = OpenBreachList(cfg.BreachList)
 		if err != nil {
-			_ = f.Close() // ignore error
-			return nil, err
+			return nil, &ConfigError{Field: "BreachList", Err: err}
 		}
-		seed = int64(binary.LittleEndian.Uint64(h.Sum(nil)[:]))
-		err = f.Close()
+	}
+	var blocklist *Blocklist
+	if cfg.NoOffensive || cfg.Blocklist != "" {
+		var err error
+		blocklist, err = LoadBlocklist(cfg.NoOffensive, cfg.Blocklist)
 		if err != nil {
-			return nil, err
+			return nil, &ConfigError{Field: "Blocklist", Err: err}
 		}
 	}
-	source := randomSource(secure, seed)
-	random := rand.New(source)
+	source := randomSource(cfg.Secure, 0)
+	if cfg.Source != nil {
+		// custom sources are shared by forks
+		source = &lockedSource{src: cfg.Source}
+	}
+	removeChars := cfg.RemoveChars
+	// custom removed chars and no-vowels rule can be used only by the random generator
+	phoneme := !cfg.Secure && !cfg.NoVowels && removeChars == ""
 
-	if ambiguous {
+	if cfg.Ambiguous {
 		removeChars += pwAmbiguous
 	}
-	if noVowels {
+	// patterns define chars classes explicitly, so only removed and ambiguous chars are excluded
+	pattern, err := compilePattern(cfg.Pattern, removeChars)
+	if err != nil {
+		return nil, &ConfigError{Field: "Pattern", Err: err}
+	}
+	if cfg.NoVowels {
 		removeChars += pwVowels
 	}
-	if noNumerals {
+	if cfg.NoNumerals {
 		removeChars += pwDigits
 	}
 
 	pg := &PwGen{
-		pwLength, numPw,
-		noNumerals, numerals, oneLine,
-		noCapitalize, ambiguous,
-		symbols, secure,
-		random, nil,
+		pwLength:     cfg.Length,
+		numPw:        cfg.Number,
+		noNumerals:   cfg.NoNumerals,
+		numerals:     cfg.Numerals,
+		oneLine:      cfg.OneLine,
+		format:       cfg.Format,
+		metadata:     cfg.Metadata,
+		hash:         cfg.Hash,
+		workers:      cfg.Workers,
+		unordered:    cfg.Unordered,
+		screenWidth:  cfg.Width,
+		onePerLine:   cfg.OnePerLine,
+		columns:      cfg.Columns,
+		noCapitalize: cfg.NoCapitalize,
+		ambiguous:    cfg.Ambiguous,
+		symbols:      cfg.Symbols,
+		secure:       cfg.Secure,
+		phoneme:      phoneme,
+		random:       rand.New(source),
+		source:       source,
+		words:        cfg.Words,
+		separator:    cfg.Separator,
+		pattern:      pattern,
+		breaches:     breaches,
+		blocklist:    blocklist,
+	}
+	switch {
+	case reader != nil:
+		pg.reader = reader
+	case pg.secure:
+		pg.reader = secureRandom
 	}
 	chars, err := pg.alphabet([]byte(removeChars))
 	if err != nil {
-		return nil, err
+		return nil, &ConfigError{Field: "RemoveChars", Err: err}
 	}
 	pg.chars = chars
+	if pg.words > 0 {
+		pg.wordList, err = LoadWordList(cfg.WordList)
+		if err != nil {
+			return nil, &ConfigError{Field: "WordList", Err: err}
+		}
+	}
+	if err = checkHashWidth(pg.hash, pg.width()); err != nil {
+		return nil, err
+	}
+	err = pg.setPolicy(cfg.Policy)
+	if err != nil {
+		return nil, &ConfigError{Field: "Policy", Err: err}
+	}
 	return pg, nil
 }
 
+// setPolicy sets the policy of random passwords adding required digits and symbols.
+// A custom policy disables the phoneme-based generator.
+func (pg *PwGen) setPolicy(policy *Policy) error {
+	for k := range pg.classes {
+		pg.classes[k] = pg.filter(classAlphabets[k])
+	}
+	if policy != nil {
+		if pg.words > 0 || pg.pattern != nil {
+			return fmt.Errorf("%w: it can not be used for passphrases or patterns", ErrPolicy)
+		}
+		pg.policy = *policy
+		pg.phoneme = false
+	}
+	min, _ := pg.policy.limits()
+	required := min[classLowers] + min[classUppers] + min[classDigits] + min[classSymbols]
+	if pg.symbols && min[classSymbols] == 0 && len(pg.classes[classSymbols]) > 0 && required < pg.pwLength {
+		pg.policy.MinSymbols = 1
+		required++
+	}
+	// a digit is added only if there is a place for another char
+	if pg.numerals && min[classDigits] == 0 && len(pg.classes[classDigits]) > 0 && required+1 < pg.pwLength {
+		pg.policy.MinDigits = 1
+	}
+	if policy == nil {
+		return nil
+	}
+	if err := pg.policy.validate(pg.pwLength, &pg.classes); err != nil {
+		return err
+	}
+	return pg.probePolicy()
+}
+
+// probePolicy returns ErrPolicy if the generator can't find a password satisfying the policy,
+// so impossible combinations of positions and classes limits fail before generation.
+// It uses a separate seeded random source, so generated passwords are not changed.
+func (pg *PwGen) probePolicy() error {
+	if !pg.policy.strict() {
+		// chars are chosen without limits, so the first attempt always succeeds
+		return nil
+	}
+	reader := pg.reader
+	pg.reader = newPCG(1, 0)
+	defer func() { pg.reader = reader }()
+	password, err := pg.generateRandom()
+	if err != nil {
+		return err
+	}
+	wipe(password)
+	return nil
+}
+
 // String returns representation string of PwGen.
 func (pg *PwGen) String() string {
 	return fmt.Sprintf("PwGen <length: %v, number:%v> from %v", pg.pwLength, pg.numPw, string(pg.chars))
 }
 
 func (pg *PwGen) choiceFromString(alphabet string) byte {
-	return alphabet[pg.random.Intn(len(alphabet))]
+	return alphabet[pg.intn(len(alphabet))]
 }
 
 func (pg *PwGen) choice(alphabet []byte) byte {
-	return alphabet[pg.random.Intn(len(alphabet))]
+	return alphabet[pg.intn(len(alphabet))]
 }
 
-// Generate returns a new random password.
+// Generate returns a new password. It is pronounceable by default,
+// but completely random if secure mode, removed chars or no-vowels rule are used.
+// It returns a passphrase if a number of words is configured
+// or a password matching a pattern if it's set.
+// It panics if the random source or breached passwords screening fails, use TryGenerate to handle such errors.
 func (pg *PwGen) Generate() string {
-	password := make([]byte, pg.pwLength)
+	password, err := pg.TryGenerate()
+	if err != nil {
+		panic(err) // fail - can't continue
+	}
+	return password
+}
 
-	n := pg.pwLength - 1
-	if pg.symbols {
-		password[n] = pg.choiceFromString(pwSymbols)
-		n--
+// TryGenerate returns a new password like Generate
+// or *RandomError if the random source fails and ErrPolicy if the policy can't be satisfied.
+// Passwords with words of the blocklist or from the breach list are rejected and generated again,
+// ErrBlocked or *BreachError is returned if there are too many such passwords
+// and *BreachError if the breach list can't be read.
+func (pg *PwGen) TryGenerate() (string, error) {
+	password, err := pg.generateBytes()
+	if err != nil {
+		return "", err
 	}
-	if !pg.noNumerals && pg.numerals && (n > 0) {
-		password[n] = pg.choiceFromString(pwDigits)
-		n--
+	defer wipe(password)
+	return string(password), nil
+}
+
+// generateBytes returns a new password buffer which is not rejected by the blocklist and the breach list.
+// Rejected buffers are wiped.
+func (pg *PwGen) generateBytes() ([]byte, error) {
+	return pg.generateScreened(pg.generate)
+}
+
+// generateScreened returns a new password buffer of the generator like generateBytes.
+func (pg *PwGen) generateScreened(generator func() ([]byte, error)) ([]byte, error) {
+	var rejected error
+	for i := 0; i < maxRejectedAttempts; i++ {
+		password, err := tryGenerate(generator)
+		if err != nil {
+			return nil, err
+		}
+		if pg.blocklist != nil && pg.blocklist.contains(password) {
+			wipe(password)
+			rejected = ErrBlocked
+			continue
+		}
+		ok, err := pg.screen(password)
+		if ok {
+			return password, nil
+		}
+		wipe(password)
+		if err != nil {
+			return nil, err
+		}
+		rejected = &BreachError{Err: ErrBreached}
 	}
-	for i := n; i >= 0; i-- {
-		password[i] = pg.choice(pg.chars)
+	return nil, rejected
+}
+
+// tryGenerate returns a new password buffer of the generator or *RandomError if the random source fails.
+func tryGenerate(generator func() ([]byte, error)) (password []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(readerError)
+			if !ok {
+				panic(r)
+			}
+			err = &RandomError{Err: e.err}
+		}
+	}()
+	return generator()
+}
+
+// generate returns a new password buffer of the configured mode.
+func (pg *PwGen) generate() ([]byte, error) {
+	switch {
+	case pg.words > 0:
+		return pg.generatePassphrase(), nil
+	case pg.pattern != nil:
+		return pg.generatePattern(), nil
+	case pg.usePhonemes():
+		return pg.generatePhonemes()
 	}
-	pg.random.Shuffle(pg.pwLength, func(i, j int) {
-		password[i], password[j] = password[j], password[i]
-	})
-	return string(password)
+	return pg.generateRandom()
+}
+
+// generateRandom returns a new random password.
+// It returns ErrPolicy if chars satisfying the policy are not found after many attempts.
+func (pg *PwGen) generateRandom() ([]byte, error) {
+	password := make([]byte, pg.pwLength)
+	for i := 0; i < maxPolicyAttempts; i++ {
+		if pg.fillRandom(password) {
+			return password, nil
+		}
+	}
+	wipe(password)
+	return nil, fmt.Errorf("%w: no password is found after %d attempts", ErrPolicy, maxPolicyAttempts)
 }
 
 // Passwords returns a channel to generate needed number of passwords.
+// All passwords have to be read from the channel,
+// use PasswordsContext or Iterator if it can be stopped earlier.
+// The channel is closed earlier if the random source fails, Iterator returns such errors.
 func (pg *PwGen) Passwords() chan string {
 	c := make(chan string)
 	go func() {
-		for i := 0; i < pg.numPw; i++ {
-			c <- pg.Generate()
-		}
-		close(c)
+		defer close(c)
+		_ = pg.generateAll(context.Background(), false, func(password []byte, _ *Record) error {
+			c <- string(password)
+			return nil
+		})
+	}()
+	return c
+}
+
+// PasswordsContext returns a channel to generate needed number of passwords.
+// The channel is closed when all passwords are generated, the context is done
+// or the random source fails.
+func (pg *PwGen) PasswordsContext(ctx context.Context) <-chan string {
+	c := make(chan string)
+	go func() {
+		defer close(c)
+		_ = pg.generateAll(ctx, false, func(password []byte, _ *Record) error {
+			select {
+			case c <- string(password):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
 	}()
 	return c
 }
 
 // Print outputs required passwords.
+// It returns *RandomError if the random source fails or an error of the writer.
 func (pg *PwGen) Print(out io.Writer) error {
-	var ended bool
-	ch := pg.Passwords()
-	if pg.oneLine {
-		// output as one line
-		for p := range ch {
-			_, err := fmt.Fprintf(out, "%s ", p)
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		// output by columns
-		i, w := 0, screenWidth/pg.pwLength
-		if w == 0 {
-			w = 1
-		}
-		for p := range ch {
-			i++
-			ended = (i % w) == 0
-			if ended {
-				_, err := fmt.Fprintln(out, p)
-				if err != nil {
-					return err
-				}
-			} else {
-				_, err := fmt.Fprintf(out, "%s ", p)
-				if err != nil {
-					return err
-				}
-			}
-		}
+	return pg.PrintContext(context.Background(), out)
+}
+
+// PrintContext outputs required passwords in the configured format until the context is done.
+func (pg *PwGen) PrintContext(ctx context.Context, out io.Writer) error {
+	return pg.WriteContext(ctx, pg.NewWriter(out))
+}
+
+// width returns a maximum length of generated passwords.
+func (pg *PwGen) width() int {
+	if pg.words > 0 {
+		return pg.passphraseWidth()
 	}
-	// new line if it's needed
-	if !ended {
-		_, err := fmt.Fprintln(out)
-		if err != nil {
-			return err
+	if pg.pattern != nil {
+		return len(pg.pattern)
+	}
+	return pg.pwLength
+}
+
+// filter returns chars of the alphabet which can be used for passwords generation.
+func (pg *PwGen) filter(alphabet string) []byte {
+	result := make([]byte, 0, len(alphabet))
+	for _, c := range pg.chars {
+		if strings.IndexByte(alphabet, c) >= 0 {
+			result = append(result, c)
 		}
 	}
-	return nil
+	return result
 }
 
 // alphabet returns byte slice of chars for passwords generation.
@@ -283,7 +535,7 @@ func (pg *PwGen) alphabet(removeChars []byte) ([]byte, error) {
 		result = byteChars
 	}
 	if len(result) < 1 {
-		return nil, errors.New("no symbols for passwords generation")
+		return nil, ErrAlphabet
 	}
 	return result, nil
 }
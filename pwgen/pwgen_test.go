@@ -8,6 +8,7 @@ package pwgen
 
 import (
 	"bytes"
+	crand "crypto/rand"
 	"errors"
 	"io/ioutil"
 	"math/rand"
@@ -257,6 +258,39 @@ func TestSecure(t *testing.T) {
 	}
 }
 
+func TestSecureUniformChars(t *testing.T) {
+	// without required classes every position is a uniform choice from the alphabet,
+	// symbols are included but not required
+	pg, err := NewWithOptions(WithSecure(true), WithNumerals(false), WithSymbols(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg.policy.MinSymbols = 0
+	n := len(pg.chars)
+	index := make(map[byte]int, n)
+	for i, c := range pg.chars {
+		index[c] = i
+	}
+	if len(index) != n {
+		t.Fatalf("alphabet has duplicates: %q", pg.chars)
+	}
+	counts := make([]int, n)
+	total := 0
+	for total < n*2000 {
+		for _, c := range []byte(pg.Generate()) {
+			i, ok := index[c]
+			if !ok {
+				t.Fatalf("unexpected char %q", c)
+			}
+			counts[i]++
+			total++
+		}
+	}
+	if x, limit := chiSquare(counts, total), chiSquareLimit(n-1); x > limit {
+		t.Errorf("not uniform distribution over alphabet: %v > %v", x, limit)
+	}
+}
+
 func TestRemoveChars(t *testing.T) {
 	pwLength := 8
 	removeChars := "abcdefghijklmnJKLMNOPQRSTUVWXYZ01234"
@@ -446,7 +480,8 @@ func TestMultiLinesPrint(t *testing.T) {
 		out = buffer.String()
 
 		le = pg.numPw*(1+pg.pwLength) + 1
-		w = screenWidth / pg.pwLength
+		// passwords and separators fit the screen
+		w = (screenWidth + 1) / (pg.pwLength + 1)
 		if w == 0 {
 			//w = 1
 			le--
@@ -563,3 +598,78 @@ func BenchmarkGenerateSecure(b *testing.B) {
 		pg.Generate()
 	}
 }
+
+func BenchmarkGenerateSecureCryptoRand(b *testing.B) {
+	pg, err := New(
+		defaultPwLength, defaultNumPw, "", "",
+		false, false, false,
+		false, false, false, false, true,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	// unbuffered crypto/rand reads
+	pg.reader = crand.Reader
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pg.Generate()
+	}
+}
+
+func TestPCG(t *testing.T) {
+	// known answers of math/rand/v2 PCG
+	values := []struct {
+		seed1, seed2 uint64
+		expected     []uint64
+	}{
+		{1, 2, []uint64{0xc4f5a58656eef510, 0x9dcec3ad077dec6c, 0xc8d04605312f8088}},
+		{42, 0, []uint64{0xdb881b72db87a99f, 0xf5f16d2d76b09fe4, 0x22e4250a897032c7}},
+	}
+	for i, v := range values {
+		p := newPCG(v.seed1, v.seed2)
+		for j, e := range v.expected {
+			if r := p.Uint64(); r != e {
+				t.Errorf("[%v/%v] unexpected value %#x", i, j, r)
+			}
+		}
+	}
+	p := newPCG(1, 2)
+	b := make([]byte, 12)
+	if n, err := p.Read(b); err != nil || n != len(b) {
+		t.Fatalf("failed read n=%v: %v", n, err)
+	}
+	expected := []byte{0x10, 0xf5, 0xee, 0x56, 0x86, 0xa5, 0xf5, 0xc4, 0x6c, 0xec, 0x7d, 0x07}
+	if !bytes.Equal(b, expected) {
+		t.Errorf("unexpected bytes %x", b)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	// known answers, they must not be changed between versions
+	values := []struct {
+		opts     []Option
+		expected string
+	}{
+		{[]Option{WithSeed(42)}, "ha9iFohd kaeTieg0 she6Do5e \n"},
+		{[]Option{WithSeed(42), WithSecure(true), WithSymbols(true), WithLength(12)}, "9I=4rP|V)IQk K4^aLWw2#t-h Sn'BU^IT0C+_ \n"},
+		{[]Option{WithSeed(-1), WithWords(3)}, "Audience-Studied1-Euphemism Sublevel1-Relatable-Bobtail Saga-Liable1-Autism \n"},
+		{[]Option{WithSeed(7), WithPattern("Cvccvc-9{2}")}, "Xugsod-56 Namwyt-47 Pydbyx-70 \n"},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(append(v.opts, WithNumber(3), WithOneLine(true))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err = pg.Print(&b); err != nil {
+			t.Fatal(err)
+		}
+		if s := b.String(); s != v.expected {
+			t.Errorf("[%v] unexpected passwords %q", i, s)
+		}
+	}
+	_, err := NewWithOptions(WithSeed(1), WithSHA1File("/root/bad_123"))
+	if !errors.Is(err, ErrDerive) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	csprngKeySize    = 32      // AES-256 key size
+	csprngBufferSize = 4096    // size of generated key stream block
+	csprngReseed     = 1 << 20 // number of generated bytes before reseeding from crypto/rand
+
+	// maxRejectedSamples is a maximum number of consecutive rejected values of uniform,
+	// every value is rejected with probability less than 1/2, so it's reached only by broken sources.
+	maxRejectedSamples = 64
+)
+
+// ErrRejected is an error of the random source which gives only rejected values.
+var ErrRejected = errors.New("too many rejected random values")
+
+// secureRandom is a shared source of secure mode.
+var secureRandom = NewCSPRNG()
+
+// CSPRNG is a buffered cryptographically secure pseudo-random generator.
+// It generates AES-256-CTR key stream by blocks, the first bytes of every block
+// replace the key (fast key erasure), so previous output can't be restored.
+// The key is reseeded from crypto/rand after every 1 MiB of output.
+// It's safe for concurrent use.
+type CSPRNG struct {
+	mu        sync.Mutex
+	key       [csprngKeySize]byte
+	buf       [csprngBufferSize]byte
+	pos       int
+	generated int
+}
+
+// NewCSPRNG returns a new buffered CSPRNG, it's seeded from crypto/rand on the first read.
+func NewCSPRNG() *CSPRNG {
+	return &CSPRNG{pos: csprngBufferSize, generated: csprngReseed}
+}
+
+// fill generates a new block of random bytes.
+func (g *CSPRNG) fill() error {
+	if g.generated >= csprngReseed {
+		if _, err := crand.Read(g.key[:]); err != nil {
+			return err
+		}
+		g.generated = 0
+	}
+	block, err := aes.NewCipher(g.key[:])
+	if err != nil {
+		return err
+	}
+	var iv [aes.BlockSize]byte
+	for i := range g.buf {
+		g.buf[i] = 0
+	}
+	cipher.NewCTR(block, iv[:]).XORKeyStream(g.buf[:], g.buf[:])
+	copy(g.key[:], g.buf[:csprngKeySize])
+	for i := 0; i < csprngKeySize; i++ {
+		g.buf[i] = 0
+	}
+	g.pos = csprngKeySize
+	g.generated += csprngBufferSize - csprngKeySize
+	return nil
+}
+
+// Read fills p by random bytes, it returns an error only if crypto/rand fails.
+func (g *CSPRNG) Read(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := 0
+	for n < len(p) {
+		if g.pos == csprngBufferSize {
+			if err := g.fill(); err != nil {
+				return n, err
+			}
+		}
+		m := copy(p[n:], g.buf[g.pos:])
+		// used bytes are erased
+		for i := g.pos; i < g.pos+m; i++ {
+			g.buf[i] = 0
+		}
+		g.pos += m
+		n += m
+	}
+	return n, nil
+}
+
+// Uint64 returns a random 64-bit integer, it panics if crypto/rand fails.
+func (g *CSPRNG) Uint64() uint64 {
+	var b [8]byte
+	if _, err := g.Read(b[:]); err != nil {
+		panic(err) // fail - can't continue
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Int63 returns a non-negative random 63-bit integer as an int64.
+func (g *CSPRNG) Int63() int64 {
+	return int64(g.Uint64() & (1<<63 - 1))
+}
+
+// Seed is fake CSPRNG Seed implementation for Source interface.
+func (g *CSPRNG) Seed(int64) {}
+
+// uniform returns a uniformly distributed random integer in [0, n).
+// It reads 32-bit values from r and rejects ones which are greater
+// than the largest multiple of n, so there is no modulo bias.
+// It returns ErrRejected if all values are rejected after many attempts.
+func uniform(r io.Reader, n int) (int, error) {
+	if n <= 0 || uint64(n) > 1<<32 {
+		panic("invalid argument to uniform")
+	}
+	if n == 1 {
+		return 0, nil
+	}
+	var b [4]byte
+	bound := uint64(n)
+	limit := 1<<32 - (1<<32)%bound
+	for i := 0; i < maxRejectedSamples; i++ {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		if v := uint64(binary.LittleEndian.Uint32(b[:])); v < limit {
+			return int(v % bound), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d values in a row are out of range", ErrRejected, maxRejectedSamples)
+}
+
+// readerError is a failure of the random reader, it interrupts passwords generation.
+type readerError struct {
+	err error
+}
+
+// RandomError is an error of the random source, passwords can't be generated after it.
+type RandomError struct {
+	Err error
+}
+
+// Error returns a text of the random source error.
+func (e *RandomError) Error() string {
+	return fmt.Sprintf("random source failed: %v", e.Err)
+}
+
+// Unwrap returns an original error.
+func (e *RandomError) Unwrap() error {
+	return e.Err
+}
+
+// intn returns a uniformly distributed random integer in [0, n).
+// Secure mode uses bytes of the CSPRNG seeded from crypto/rand,
+// a custom reader is used if it's set.
+// A reader failure panics with readerError, it's recovered by generate.
+func (pg *PwGen) intn(n int) int {
+	if pg.reader == nil {
+		return pg.random.Intn(n)
+	}
+	v, err := uniform(pg.reader, n)
+	if err != nil {
+		panic(readerError{err})
+	}
+	return v
+}
+
+// shuffle pseudo-randomizes the order of n elements using Fisher-Yates algorithm.
+func (pg *PwGen) shuffle(n int, swap func(i, j int)) {
+	if pg.reader == nil {
+		pg.random.Shuffle(n, swap)
+		return
+	}
+	for i := n - 1; i > 0; i-- {
+		swap(i, pg.intn(i+1))
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// errRead is an error of failReader.
+var errRead = errors.New("read failed")
+
+// failReader returns n random bytes and errRead after them.
+type failReader struct {
+	n int
+}
+
+func (r *failReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, errRead
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	n, err := crand.Read(p)
+	r.n -= n
+	return n, err
+}
+
+// constReader returns the same byte forever.
+type constReader byte
+
+func (r constReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+// chiSquareLimit returns an approximate critical value of chi-squared distribution
+// with df degrees of freedom for 1e-6 significance level (Wilson-Hilferty transformation).
+func chiSquareLimit(df int) float64 {
+	const z = 4.75
+	k := 2.0 / (9.0 * float64(df))
+	return float64(df) * math.Pow(1-k+z*math.Sqrt(k), 3)
+}
+
+// chiSquare returns chi-squared statistic of counts for uniform distribution.
+func chiSquare(counts []int, total int) float64 {
+	var result float64
+	expected := float64(total) / float64(len(counts))
+	for _, c := range counts {
+		d := float64(c) - expected
+		result += d * d / expected
+	}
+	return result
+}
+
+func TestUniform(t *testing.T) {
+	values := []struct {
+		data     []byte
+		n        int
+		expected int
+	}{
+		{[]byte{5, 0, 0, 0}, 1, 0},
+		{[]byte{5, 0, 0, 0}, 3, 2},
+		// 0xFFFFFFFF is rejected for n=3, because 2^32 % 3 = 1
+		{[]byte{0xFF, 0xFF, 0xFF, 0xFF, 7, 0, 0, 0}, 3, 1},
+		// 2^32 % 94 = 42 largest values are rejected
+		{[]byte{0xD6, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xD5, 0xFF, 0xFF, 0xFF}, 94, 93},
+	}
+	for i, v := range values {
+		r, err := uniform(bytes.NewReader(v.data), v.n)
+		if err != nil {
+			t.Errorf("[%v] unexpected error: %v", i, err)
+			continue
+		}
+		if r != v.expected {
+			t.Errorf("[%v] unexpected value %v", i, r)
+		}
+	}
+	_, err := uniform(bytes.NewReader([]byte{1, 2}), 10)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err = uniform(constReader(0xFF), 3)
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("unexpected rejected error: %v", err)
+	}
+}
+
+func TestSecureUniformShuffle(t *testing.T) {
+	pg, err := NewWithOptions(WithSecure(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all 24 permutations of 4 elements
+	total := 24 * 2000
+	permutations := make(map[[4]byte]int, 24)
+	for i := 0; i < total; i++ {
+		p := [4]byte{'a', 'b', 'c', 'd'}
+		pg.shuffle(len(p), func(i, j int) { p[i], p[j] = p[j], p[i] })
+		permutations[p]++
+	}
+	if n := len(permutations); n != 24 {
+		t.Fatalf("unexpected number of permutations: %v", n)
+	}
+	counts := make([]int, 0, 24)
+	for _, c := range permutations {
+		counts = append(counts, c)
+	}
+	if x, limit := chiSquare(counts, total), chiSquareLimit(23); x > limit {
+		t.Errorf("not uniform shuffle: %v > %v", x, limit)
+	}
+}
+
+func TestCSPRNG(t *testing.T) {
+	g := NewCSPRNG()
+	// reads cross blocks borders
+	total := 3*csprngBufferSize + 100
+	data := make([]byte, total)
+	for i := 0; i < total; i += 333 {
+		j := i + 333
+		if j > total {
+			j = total
+		}
+		if n, err := g.Read(data[i:j]); err != nil || n != j-i {
+			t.Fatalf("failed read n=%v: %v", n, err)
+		}
+	}
+	counts := make([]int, 256)
+	for _, b := range data {
+		counts[b]++
+	}
+	if x, limit := chiSquare(counts, total), chiSquareLimit(255); x > limit {
+		t.Errorf("not uniform bytes: %v > %v", x, limit)
+	}
+	// used bytes are erased
+	for i := 0; i < g.pos; i++ {
+		if g.buf[i] != 0 {
+			t.Fatalf("not erased byte %v", i)
+		}
+	}
+	if v := g.Int63(); v < 0 {
+		t.Errorf("negative value %v", v)
+	}
+}
+
+func TestCSPRNGReseed(t *testing.T) {
+	g := NewCSPRNG()
+	if _, err := g.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if g.generated != csprngBufferSize-csprngKeySize {
+		t.Errorf("unexpected generated bytes: %v", g.generated)
+	}
+	g.generated = csprngReseed
+	g.pos = csprngBufferSize
+	if _, err := g.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if g.generated != csprngBufferSize-csprngKeySize {
+		t.Errorf("not reseeded, generated bytes: %v", g.generated)
+	}
+}
+
+func TestCSPRNGConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	g := NewCSPRNG()
+	results := make([][]byte, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = make([]byte, csprngBufferSize)
+			if _, err := g.Read(results[i]); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		for j := i + 1; j < len(results); j++ {
+			if bytes.Equal(results[i][:64], results[j][:64]) {
+				t.Errorf("repeated output %v and %v", i, j)
+			}
+		}
+	}
+}
+
+func BenchmarkCryptoRandRead(b *testing.B) {
+	var buf [8]byte
+	for n := 0; n < b.N; n++ {
+		if _, err := crand.Read(buf[:]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCSPRNGRead(b *testing.B) {
+	var buf [8]byte
+	g := NewCSPRNG()
+	for n := 0; n < b.N; n++ {
+		if _, err := g.Read(buf[:]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCSPRNGReadParallel(b *testing.B) {
+	g := NewCSPRNG()
+	b.RunParallel(func(pb *testing.PB) {
+		var buf [8]byte
+		for pb.Next() {
+			if _, err := g.Read(buf[:]); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestReader(t *testing.T) {
+	data := []byte{1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 19, 0, 0, 0}
+	p, err := GeneratePattern("9{4}", WithReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != "1239" {
+		t.Errorf("unexpected password %v", p)
+	}
+	// the stream is over
+	_, err = GeneratePattern("9{5}", WithReader(bytes.NewReader(data)))
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	values := [][]Option{
+		{},
+		{WithSecure(true)},
+		{WithSymbols(true), WithAmbiguous(true), WithLength(12)},
+		{WithPolicy(Policy{MinDigits: 2, MaxConsecutive: 1})},
+		{WithWords(4)},
+		{WithPattern("Cvccvc-9{2}")},
+	}
+	for i, opts := range values {
+		for _, n := range []int{0, 3, 20, 100} {
+			pg, err := NewWithOptions(append(opts, WithReader(&failReader{n: n}))...)
+			if err != nil {
+				t.Fatalf("[%v] %v", i, err)
+			}
+			var b strings.Builder
+			if err = pg.Print(&b); !errors.Is(err, errRead) {
+				t.Errorf("[%v/%v] unexpected error: %v", i, n, err)
+			}
+			it := pg.Iterator(context.Background())
+			for it.Next() {
+			}
+			if err = it.Err(); !errors.Is(err, errRead) {
+				t.Errorf("[%v/%v] unexpected iterator error: %v", i, n, err)
+			}
+		}
+		// all values of a constant stream are rejected
+		pg, err := NewWithOptions(append(opts, WithReader(constReader(0xFF)))...)
+		if err != nil {
+			t.Fatalf("[%v] %v", i, err)
+		}
+		var b strings.Builder
+		if err = pg.Print(&b); !errors.Is(err, ErrRejected) {
+			t.Errorf("[%v] unexpected constant reader error: %v", i, err)
+		}
+	}
+	// pronounceable passwords can't be generated from zero bytes
+	pg, err := NewWithOptions(WithReader(constReader(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err = pg.Print(&b); !errors.Is(err, ErrRejected) {
+		t.Errorf("unexpected zero reader error: %v", err)
+	}
+}
+
+func TestTryGenerate(t *testing.T) {
+	pg, err := NewWithOptions(WithReader(&failReader{n: 1000}), WithSymbols(true), WithPolicy(Policy{MinSymbols: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		p, err := pg.TryGenerate()
+		if err != nil {
+			var e *RandomError
+			if !errors.As(err, &e) || e.Err != errRead {
+				t.Errorf("unexpected error: %v", err)
+			}
+			break
+		}
+		if len(p) != defaultPwLength {
+			t.Errorf("unexpected password %v", p)
+		}
+	}
+	n := 0
+	for range pg.Passwords() {
+		n++
+	}
+	if n != 0 {
+		t.Errorf("unexpected number of passwords %v", n)
+	}
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || !errors.Is(err, errRead) {
+			t.Errorf("unexpected panic: %v", r)
+		}
+	}()
+	pg.Generate()
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"io"
+	"runtime"
+)
+
+// redacted is a text representation of secrets.
+const redacted = "[secret]"
+
+// wipe overwrites the buffer by zeros.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	// keep the buffer alive, so zeroing is not removed as a dead store
+	runtime.KeepAlive(b)
+}
+
+// Secret is a generated password in a byte buffer which can be wiped after usage.
+// Go strings are immutable and stay in the memory until the garbage collector reuses it,
+// so Secret never converts the password to a string, and String returns a redacted text.
+type Secret struct {
+	b []byte
+}
+
+// NewSecret returns a new secret which owns the buffer, it's wiped by Destroy.
+func NewSecret(b []byte) *Secret {
+	return &Secret{b: b}
+}
+
+// Bytes returns the password buffer, it's valid until Destroy is called.
+// The buffer must not be modified or kept by the caller.
+func (s *Secret) Bytes() []byte {
+	return s.b
+}
+
+// Len returns a length of the password, it's zero after Destroy.
+func (s *Secret) Len() int {
+	return len(s.b)
+}
+
+// String returns a redacted text, so the password is not printed or logged by an accident.
+func (s *Secret) String() string {
+	return redacted
+}
+
+// WriteTo writes the password to w without intermediate copies.
+func (s *Secret) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(s.b)
+	return int64(n), err
+}
+
+// Destroy wipes the password buffer, it's safe to call it several times.
+func (s *Secret) Destroy() {
+	wipe(s.b)
+	s.b = nil
+}
+
+// MaxLength returns a maximum length of generated passwords,
+// it's a buffer size which is enough for GenerateBytes.
+func (pg *PwGen) MaxLength() int {
+	return pg.width()
+}
+
+// GenerateSecret returns a new password like TryGenerate but as a secret buffer.
+// The caller should call Destroy after usage.
+func (pg *PwGen) GenerateSecret() (*Secret, error) {
+	password, err := pg.generateBytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewSecret(password), nil
+}
+
+// GenerateBytes writes a new password to the caller-owned buffer and returns its length.
+// It returns io.ErrShortBuffer if dst is shorter than the password, see MaxLength.
+// Other errors are the same as TryGenerate ones. Internal copies are wiped.
+func (pg *PwGen) GenerateBytes(dst []byte) (int, error) {
+	password, err := pg.generateBytes()
+	if err != nil {
+		return 0, err
+	}
+	defer wipe(password)
+	if len(password) > len(dst) {
+		return 0, io.ErrShortBuffer
+	}
+	return copy(dst, password), nil
+}
+
+// appendJSONString appends the value as JSON string content without quotes,
+// it escapes chars like encoding/json without HTML escaping.
+func appendJSONString(dst, value []byte) []byte {
+	const hex = "0123456789abcdef"
+	for _, c := range value {
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c == '\r':
+			dst = append(dst, '\\', 'r')
+		case c == '\t':
+			dst = append(dst, '\\', 't')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestGenerateBytes(t *testing.T) {
+	pg, err := NewWithOptions(WithSeed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := pg.MaxLength(); n != defaultPwLength {
+		t.Errorf("unexpected max length %v", n)
+	}
+	dst := make([]byte, 16)
+	n, err := pg.GenerateBytes(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := string(dst[:n]); p != "ha9iFohd" {
+		t.Errorf("unexpected password %q", p)
+	}
+	n, err = pg.GenerateBytes(dst[:4])
+	if !errors.Is(err, io.ErrShortBuffer) || n != 0 {
+		t.Errorf("unexpected result %v, %v", n, err)
+	}
+	if p := string(dst[:4]); p != "ha9i" {
+		t.Errorf("short buffer is changed: %q", dst[:4])
+	}
+	pg, err = NewWithOptions(WithReader(&failReader{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var re *RandomError
+	if _, err = pg.GenerateBytes(dst); !errors.As(err, &re) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	pg, err := NewWithOptions(WithSeed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := pg.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := s.Bytes()
+	if p := string(b); p != "ha9iFohd" || s.Len() != 8 {
+		t.Errorf("unexpected secret %q", p)
+	}
+	if v := fmt.Sprintf("%v %s", s, s); v != "[secret] [secret]" {
+		t.Errorf("secret is not redacted: %v", v)
+	}
+	var out bytes.Buffer
+	if n, err := s.WriteTo(&out); err != nil || n != 8 || out.String() != "ha9iFohd" {
+		t.Errorf("unexpected output %q, %v, %v", out.String(), n, err)
+	}
+	s.Destroy()
+	if !bytes.Equal(b, make([]byte, 8)) {
+		t.Errorf("buffer is not wiped: %q", b)
+	}
+	if s.Len() != 0 || s.Bytes() != nil {
+		t.Error("secret is not destroyed")
+	}
+	s.Destroy()
+}
+
+func TestIteratorBytes(t *testing.T) {
+	pg, err := NewWithOptions(WithSeed(42), WithNumber(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := pg.Iterator(context.Background())
+	var buffers [][]byte
+	for it.Next() {
+		if b := it.Bytes(); string(b) != it.Password() {
+			t.Errorf("unexpected buffer %q", b)
+		} else {
+			buffers = append(buffers, b)
+		}
+	}
+	if err = it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(buffers) != 2 {
+		t.Fatalf("unexpected number %v", len(buffers))
+	}
+	for i, b := range buffers {
+		if !bytes.Equal(b, make([]byte, len(b))) {
+			t.Errorf("[%v] buffer is not wiped: %q", i, b)
+		}
+	}
+}
+
+func TestWriteSecret(t *testing.T) {
+	passwords := []string{"ha9iFohd", `a"b\c`, "x\ny\tz\x01<&>", "word-Word-wörd"}
+	values := []func(io.Writer) secretWriter{
+		func(out io.Writer) secretWriter { return NewTextWriter(out, 2) },
+		func(out io.Writer) secretWriter { return NewJSONWriter(out) },
+		func(out io.Writer) secretWriter { return NewNDJSONWriter(out) },
+		func(out io.Writer) secretWriter { return NewCSVWriter(out, true, true) },
+	}
+	for i, v := range values {
+		var expected, actual bytes.Buffer
+		we, wa := v(&expected), v(&actual)
+		for _, p := range passwords {
+			r := &Record{Length: len(p), Hash: "hash"}
+			if err := wa.writeSecret([]byte(p), r); err != nil {
+				t.Fatal(err)
+			}
+			r.Password = p
+			if err := we.Write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := we.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := wa.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if e, a := expected.String(), actual.String(); e != a {
+			t.Errorf("[%v] unexpected output %q, expected %q", i, a, e)
+		}
+	}
+	// CSV rows are quoted like encoding/csv does
+	var expected, actual bytes.Buffer
+	cw, w := csv.NewWriter(&expected), NewCSVWriter(&actual, false, true)
+	if err := cw.Write([]string{"password", "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range append(passwords, " a", "a,b", `\.`, "", "a\r\nb", "\u00a0x") {
+		if err := cw.Write([]string{p, "hash"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.writeSecret([]byte(p), &Record{Hash: "hash"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cw.Flush()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := expected.String(), actual.String(); e != a {
+		t.Errorf("unexpected CSV output %q, expected %q", a, e)
+	}
+	var out bytes.Buffer
+	jw := NewJSONWriter(&out)
+	for _, p := range passwords {
+		if err := jw.writeSecret([]byte(p), &Record{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := jw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range records {
+		if r.Password != passwords[i] {
+			t.Errorf("[%v] unexpected password %q", i, r.Password)
+		}
+	}
+}
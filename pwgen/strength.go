@@ -0,0 +1,604 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	_ "embed" // embedded common passwords
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Weakness kinds of checked passwords.
+const (
+	WeaknessDictionary = "dictionary" // common password or dictionary word, l33t substitutions are detected
+	WeaknessKeyboard   = "keyboard"   // walk of adjacent keys of QWERTY keyboard, ie: "qwerty", "zaq1"
+	WeaknessRepeat     = "repeat"     // repeated char or substring, ie: "aaa", "abcabc"
+	WeaknessSequence   = "sequence"   // sequence of letters or digits, ie: "abc", "9876"
+	WeaknessDate       = "date"       // year or date, ie: "1987", "12/05/90"
+)
+
+const (
+	maxCheckLength    = 128 // longer passwords have only random chars after this length
+	minDictionaryWord = 4   // minimal length of dictionary words matches
+	minKeyboardWalk   = 4   // minimal length of keyboard walks
+	minSequence       = 3   // minimal length of sequences
+	minYearSpace      = 20  // minimal number of guessed years
+	keyboardKeys      = 47  // number of keys with chars
+	keyboardDegree    = 4   // average number of neighbour keys used by walks
+)
+
+// scoreBits are minimal bits of entropy of scores from 1 to 4.
+var scoreBits = [...]float64{28, 36, 60, 80}
+
+var (
+	//go:embed wordlists/common_passwords.txt
+	commonPasswordsList string
+	// commonPasswords is a lazy parsed list of common passwords ordered by popularity.
+	commonPasswords = &embeddedWordList{data: commonPasswordsList}
+
+	dictionaryOnce sync.Once
+	dictionary     map[string]int // ranks of words
+	dictionaryMax  int            // maximal length of words
+
+	// leetChars are replacements of l33t substitutions, '1' and '|' are 'i' or 'l'.
+	leetChars = map[byte]string{
+		'4': "a", '@': "a", '8': "b", '(': "c", '{': "c", '[': "c", '<': "c", '3': "e", '6': "g", '9': "g",
+		'1': "il", '!': "i", '|': "il", '0': "o", '$': "s", '5': "s", '7': "t", '+': "t", '2': "z",
+	}
+
+	// keyboardRows are rows of QWERTY keyboard, every row is shifted
+	// so a key is adjacent to keys with the same and the next index of the previous row.
+	keyboardRows = [...]string{"`1234567890-=", "\x00qwertyuiop[]\\", "\x00asdfghjkl;'", "\x00zxcvbnm,./"}
+	// keyboardShifted are shifted keys with the same positions as keyboardRows.
+	keyboardShifted = [...]string{"~!@#$%^&*()_+", "\x00QWERTYUIOP{}|", "\x00ASDFGHJKL:\"", "\x00ZXCVBNM<>?"}
+	keyboardOnce    sync.Once
+	keyboard        [256]keyPosition
+
+	dateSeparated = regexp.MustCompile(`^(\d{1,4})([-/._ ])(\d{1,2})([-/._ ])(\d{1,4})$`)
+)
+
+// keyPosition is a position of the key on the keyboard.
+type keyPosition struct {
+	row, col int
+	shifted  bool
+	ok       bool
+}
+
+// Weakness is a weak part of the checked password.
+type Weakness struct {
+	Kind    string  `json:"kind"`
+	Start   int     `json:"start"` // index of the first char
+	End     int     `json:"end"`   // index after the last char
+	Entropy float64 `json:"entropy"`
+	Token   string  `json:"-"` // part of the password, it's not marshaled to not disclose it
+}
+
+// Strength is a report of the password strength.
+// Entropy is an estimated number of bits of guesses which an attacker needs using
+// dictionaries and patterns, Score is from 0 (very weak) to 4 (very strong).
+type Strength struct {
+	Length     int        `json:"length"`
+	Lowers     int        `json:"lowers"`
+	Uppers     int        `json:"uppers"`
+	Digits     int        `json:"digits"`
+	Symbols    int        `json:"symbols"`
+	Entropy    float64    `json:"entropy"`
+	Score      int        `json:"score"`
+	Weaknesses []Weakness `json:"weaknesses,omitempty"`
+	Violations []string   `json:"violations,omitempty"`
+}
+
+// CheckStrength returns the strength report of the password, zxcvbn-style.
+// The password is split to weak parts and random chars with a minimal total entropy,
+// random chars cost bits of the alphabet of all used chars classes.
+func CheckStrength(password string) *Strength {
+	s := &Strength{Length: len(password)}
+	var counts [numClasses]int
+	for i := 0; i < len(password); i++ {
+		counts[charClass(password[i])]++
+	}
+	s.Lowers, s.Uppers, s.Digits, s.Symbols = counts[classLowers], counts[classUppers], counts[classDigits], counts[classSymbols]
+	charBits, checked := bruteBits(password), password
+	if len(checked) > maxCheckLength {
+		checked = checked[:maxCheckLength]
+	}
+	bits, weaknesses := minEntropy(checked, charBits)
+	bits += float64(len(password)-len(checked)) * charBits
+	s.Entropy, s.Weaknesses = math.Round(bits*100)/100, weaknesses
+	for _, b := range scoreBits {
+		if bits >= b {
+			s.Score++
+		}
+	}
+	return s
+}
+
+// Check returns the strength report of the password with violations of generation rules:
+// the policy, blocked words, required chars classes, the length, chars of disabled classes
+// and removed chars of random passwords. Violations contain only classes, numbers and positions of chars,
+// so they don't disclose the password.
+func (pg *PwGen) Check(password string) *Strength {
+	s := CheckStrength(password)
+	s.Violations = pg.policy.violations(password)
+	if pg.blocklist != nil && pg.blocklist.Contains(password) {
+		s.Violations = append(s.Violations, "blocked word")
+	}
+	if pg.words > 0 || pg.pattern != nil {
+		return s
+	}
+	// shorter passwords than the policy minimum are already reported
+	if n := len(password); n < pg.pwLength && n >= pg.policy.MinLength {
+		s.Violations = append(s.Violations, fmt.Sprintf("length %d is less than %d", n, pg.pwLength))
+	}
+	disabled, removed := pg.excludedChars(password)
+	for k, n := range disabled {
+		if n > 0 {
+			s.Violations = append(s.Violations, fmt.Sprintf("%d disabled %s", n, classNames[k]))
+		}
+	}
+	if removed > 0 {
+		s.Violations = append(s.Violations, fmt.Sprintf("%d removed chars", removed))
+	}
+	return s
+}
+
+// excludedChars returns numbers of chars of the password which are not used by random passwords:
+// chars of disabled classes (capitals, numerals or symbols) and excluded chars of enabled classes.
+func (pg *PwGen) excludedChars(password string) (disabled [numClasses]int, removed int) {
+	enabled := [numClasses]bool{true, !pg.noCapitalize, !pg.noNumerals, pg.symbols}
+	for i := 0; i < len(password); i++ {
+		c := password[i]
+		k := charClass(c)
+		switch {
+		case strings.IndexByte(classAlphabets[k], c) < 0:
+			// other chars are not generated by any options
+		case !enabled[k]:
+			disabled[k]++
+		case !containsByte(pg.chars, c):
+			removed++
+		}
+	}
+	return disabled, removed
+}
+
+// containsByte returns true if the chars contain c.
+func containsByte(chars []byte, c byte) bool {
+	for _, x := range chars {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// bruteBits returns bits of a random char of the password alphabet.
+func bruteBits(password string) float64 {
+	var used [numClasses]bool
+	other := false
+	for i := 0; i < len(password); i++ {
+		if password[i] >= 0x80 {
+			other = true
+			continue
+		}
+		used[charClass(password[i])] = true
+	}
+	size := 0
+	for k, ok := range used {
+		if ok {
+			size += len(classAlphabets[k])
+		}
+	}
+	if other {
+		size += 0x80
+	}
+	if size < 2 {
+		return 1
+	}
+	return math.Log2(float64(size))
+}
+
+// minEntropy returns a minimal entropy of the password split to weak parts and random chars.
+func minEntropy(password string, charBits float64) (float64, []Weakness) {
+	return splitEntropy(password, charBits, findWeaknesses(password))
+}
+
+// splitEntropy returns a minimal entropy of the password split to the matches and random chars.
+func splitEntropy(password string, charBits float64, matches []Weakness) (float64, []Weakness) {
+	n := len(password)
+	best := make([]float64, n+1)
+	prev := make([]int, n+1) // index of the last match or -1 for a random char
+	for j := 1; j <= n; j++ {
+		best[j], prev[j] = best[j-1]+charBits, -1
+		for k, m := range matches {
+			if m.End == j && best[m.Start]+m.Entropy < best[j] {
+				best[j], prev[j] = best[m.Start]+m.Entropy, k
+			}
+		}
+	}
+	var weaknesses []Weakness
+	for j := n; j > 0; {
+		k := prev[j]
+		if k < 0 {
+			j--
+			continue
+		}
+		m := matches[k]
+		m.Entropy = math.Round(m.Entropy*100) / 100
+		weaknesses = append([]Weakness{m}, weaknesses...)
+		j = m.Start
+	}
+	return best[n], weaknesses
+}
+
+// findWeaknesses returns all weak parts of the password.
+func findWeaknesses(password string) []Weakness {
+	return append(patternMatches(password), repeatMatches(password)...)
+}
+
+// patternMatches returns weak parts of the password except repeats.
+func patternMatches(password string) []Weakness {
+	var matches []Weakness
+	matches = append(matches, dictionaryMatches(password)...)
+	matches = append(matches, keyboardMatches(password)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, dateMatches(password)...)
+	return matches
+}
+
+// loadDictionary parses common passwords and EFF's large word list.
+func loadDictionary() {
+	words := embeddedWordLists[WordListLarge].list()
+	common := commonPasswords.list()
+	dictionary = make(map[string]int, len(common)+len(words))
+	for i, word := range common {
+		dictionary[word] = i + 1
+	}
+	for _, word := range words {
+		if _, ok := dictionary[word]; !ok {
+			// all words of the list are equiprobable
+			dictionary[word] = len(words)
+		}
+	}
+	for word := range dictionary {
+		if len(word) > dictionaryMax {
+			dictionaryMax = len(word)
+		}
+	}
+}
+
+// leetVariants returns lower case variants of s with replaced l33t substitutions,
+// the first one has no replacements. All variants have the same length as s.
+func leetVariants(s string) []string {
+	buffers := leetBytes([]byte(s))
+	variants := make([]string, len(buffers))
+	for i, b := range buffers {
+		variants[i] = string(b)
+	}
+	return variants
+}
+
+// leetBytes is like leetVariants but returns new buffers which can be wiped.
+// Only ASCII letters are lowered, so all variants have the same length as p.
+func leetBytes(p []byte) [][]byte {
+	lower := make([]byte, len(p))
+	for i, c := range p {
+		lower[i] = lowerByte(c)
+	}
+	variants := [][]byte{lower}
+	for k := 0; k < 2; k++ {
+		b := make([]byte, len(lower))
+		copy(b, lower)
+		changed := false
+		for i := range b {
+			if r, ok := leetChars[b[i]]; ok {
+				b[i] = r[k%len(r)]
+				changed = true
+			}
+		}
+		if changed && (k == 0 || !bytes.Equal(b, variants[len(variants)-1])) {
+			variants = append(variants, b)
+		} else {
+			wipe(b)
+		}
+	}
+	return variants
+}
+
+// lowerByte returns a lower case ASCII letter or c as is.
+func lowerByte(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// dictionaryMatches returns common passwords and dictionary words of the password.
+func dictionaryMatches(password string) []Weakness {
+	dictionaryOnce.Do(loadDictionary)
+	var matches []Weakness
+	for _, variant := range leetVariants(password) {
+		for i := 0; i < len(variant); i++ {
+			for j := i + minDictionaryWord; j <= len(variant) && j-i <= dictionaryMax; j++ {
+				rank, ok := dictionary[variant[i:j]]
+				if !ok {
+					continue
+				}
+				token := password[i:j]
+				bits := math.Log2(float64(rank)) + caseBits(token) + float64(leetCount(token, variant[i:j]))
+				matches = append(matches, Weakness{Kind: WeaknessDictionary, Start: i, End: j, Entropy: bits, Token: token})
+			}
+		}
+	}
+	return matches
+}
+
+// caseBits returns bits of capital letters variations of the word.
+func caseBits(word string) float64 {
+	uppers, lowers := 0, 0
+	for i := 0; i < len(word); i++ {
+		switch charClass(word[i]) {
+		case classUppers:
+			uppers++
+		case classLowers:
+			lowers++
+		}
+	}
+	switch {
+	case uppers == 0:
+		return 0
+	case lowers == 0 || (uppers == 1 && charClass(word[0]) == classUppers):
+		// all capital letters or the first one only
+		return 1
+	}
+	return float64(uppers + 1)
+}
+
+// leetCount returns a number of l33t substitutions in the token.
+func leetCount(token, word string) int {
+	n := 0
+	for i := 0; i < len(token); i++ {
+		if c := token[i]; charClass(c) != classLowers && charClass(c) != classUppers && c != word[i] {
+			n++
+		}
+	}
+	return n
+}
+
+// loadKeyboard fills positions of keyboard keys.
+func loadKeyboard() {
+	for row := range keyboardRows {
+		for col := 0; col < len(keyboardRows[row]); col++ {
+			if c := keyboardRows[row][col]; c != 0 {
+				keyboard[c] = keyPosition{row: row, col: col, ok: true}
+			}
+			if c := keyboardShifted[row][col]; c != 0 {
+				keyboard[c] = keyPosition{row: row, col: col, shifted: true, ok: true}
+			}
+		}
+	}
+}
+
+// keyboardAdjacent returns true if keys of chars are neighbours.
+func keyboardAdjacent(a, b byte) bool {
+	pa, pb := keyboard[a], keyboard[b]
+	if !pa.ok || !pb.ok {
+		return false
+	}
+	dc := pb.col - pa.col
+	switch pb.row - pa.row {
+	case 0:
+		return dc == 1 || dc == -1
+	case 1:
+		return dc == 0 || dc == -1
+	case -1:
+		return dc == 0 || dc == 1
+	}
+	return false
+}
+
+// keyboardMatches returns walks of adjacent keys of the password.
+func keyboardMatches(password string) []Weakness {
+	keyboardOnce.Do(loadKeyboard)
+	var matches []Weakness
+	for i := 0; i < len(password); {
+		j := i + 1
+		for j < len(password) && keyboardAdjacent(password[j-1], password[j]) {
+			j++
+		}
+		if j-i >= minKeyboardWalk {
+			token := password[i:j]
+			bits := math.Log2(keyboardKeys) + float64(j-i-1)*math.Log2(keyboardDegree)
+			for k := 0; k < len(token); k++ {
+				if keyboard[token[k]].shifted {
+					bits++
+					break
+				}
+			}
+			matches = append(matches, Weakness{Kind: WeaknessKeyboard, Start: i, End: j, Entropy: bits, Token: token})
+		}
+		i = j
+	}
+	return matches
+}
+
+// repeatMatches returns maximal repeats of chars and substrings of the password.
+// A repeated substring costs bits of the substring without nested repeats and a number of repeats.
+func repeatMatches(password string) []Weakness {
+	var matches []Weakness
+	baseBits := make(map[string]float64) // entropy of distinct repeated substrings
+	n := len(password)
+	for i := 0; i < n; i++ {
+		for size := 1; i+2*size <= n; size++ {
+			base := password[i : i+size]
+			if i >= size && password[i-size:i] == base {
+				// a part of the repeat which starts earlier
+				continue
+			}
+			if strings.Index((base + base)[1:], base)+1 < size {
+				// the base is a repeat of a shorter substring, ie: "abab"
+				continue
+			}
+			count := 1
+			for i+(count+1)*size <= n && password[i+count*size:i+(count+1)*size] == base {
+				count++
+			}
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			bits, ok := baseBits[base]
+			if !ok {
+				bits, _ = splitEntropy(base, bruteBits(base), patternMatches(base))
+				baseBits[base] = bits
+			}
+			j := i + count*size
+			matches = append(matches, Weakness{
+				Kind: WeaknessRepeat, Start: i, End: j, Entropy: bits + math.Log2(float64(count)), Token: password[i:j],
+			})
+		}
+	}
+	return matches
+}
+
+// sequenceMatches returns sequences of lower or capital letters or digits with step 1 or -1.
+func sequenceMatches(password string) []Weakness {
+	var matches []Weakness
+	for i := 0; i+1 < len(password); {
+		class := charClass(password[i])
+		step := int(password[i+1]) - int(password[i])
+		j := i + 1
+		if class != classSymbols && (step == 1 || step == -1) {
+			for j < len(password) && charClass(password[j]) == class && int(password[j])-int(password[j-1]) == step {
+				j++
+			}
+		}
+		if j-i < minSequence {
+			i++
+			continue
+		}
+		token := password[i:j]
+		var bits float64
+		switch {
+		case strings.IndexByte("aAzZ019", token[0]) >= 0:
+			bits = 2
+		case class == classDigits:
+			bits = math.Log2(10)
+		default:
+			bits = math.Log2(26)
+		}
+		if step < 0 {
+			bits++
+		}
+		bits += math.Log2(float64(j - i))
+		matches = append(matches, Weakness{Kind: WeaknessSequence, Start: i, End: j, Entropy: bits, Token: token})
+		i = j
+	}
+	return matches
+}
+
+// yearBits returns bits of the guessed year.
+func yearBits(year int) float64 {
+	space := year - time.Now().Year()
+	if space < 0 {
+		space = -space
+	}
+	if space < minYearSpace {
+		space = minYearSpace
+	}
+	return math.Log2(float64(space))
+}
+
+// fullYear returns a year of 2 or 4 digits value.
+func fullYear(s string) int {
+	year, _ := strconv.Atoi(s)
+	switch {
+	case len(s) != 2:
+		return year
+	case year > 50:
+		return 1900 + year
+	}
+	return 2000 + year
+}
+
+// validDate returns true if the year, month and day are possible.
+func validDate(year, month, day int) bool {
+	return year >= 1900 && year < 2100 && month >= 1 && month <= 12 && day >= 1 && day <= 31
+}
+
+// parseDate returns a year of the date or false if s is not a date.
+// Years, dates without separators like "ddmmyy", "mmddyyyy", "yyyymmdd" and
+// dates with "-/._ " separators are recognized.
+func parseDate(s string) (int, bool) {
+	if m := dateSeparated.FindStringSubmatch(s); m != nil {
+		if m[2] != m[4] {
+			return 0, false
+		}
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[3])
+		c, _ := strconv.Atoi(m[5])
+		if len(m[1]) == 4 {
+			return a, len(m[5]) <= 2 && validDate(a, b, c)
+		}
+		if len(m[1]) > 2 || (len(m[5]) != 2 && len(m[5]) != 4) {
+			return 0, false
+		}
+		year := fullYear(m[5])
+		return year, validDate(year, b, a) || validDate(year, a, b)
+	}
+	for i := 0; i < len(s); i++ {
+		if charClass(s[i]) != classDigits {
+			return 0, false
+		}
+	}
+	// splits of digits to year, month and day
+	var layouts []string
+	switch len(s) {
+	case 4:
+		year, _ := strconv.Atoi(s)
+		return year, validDate(year, 1, 1)
+	case 6:
+		layouts = []string{"ddmmyy", "mmddyy", "yymmdd"}
+	case 8:
+		layouts = []string{"ddmmyyyy", "mmddyyyy", "yyyymmdd"}
+	}
+	for _, layout := range layouts {
+		y, m, d := strings.IndexByte(layout, 'y'), strings.IndexByte(layout, 'm'), strings.IndexByte(layout, 'd')
+		year := fullYear(s[y : y+len(layout)-4])
+		month, _ := strconv.Atoi(s[m : m+2])
+		day, _ := strconv.Atoi(s[d : d+2])
+		if validDate(year, month, day) {
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+// dateMatches returns years and dates of the password.
+func dateMatches(password string) []Weakness {
+	var matches []Weakness
+	for i := 0; i < len(password); i++ {
+		for j := i + 4; j <= len(password) && j-i <= 10; j++ {
+			token := password[i:j]
+			year, ok := parseDate(token)
+			if !ok {
+				continue
+			}
+			bits := yearBits(year)
+			if len(token) > 4 {
+				bits += math.Log2(365)
+				if dateSeparated.MatchString(token) {
+					bits += 2
+				}
+			}
+			matches = append(matches, Weakness{Kind: WeaknessDate, Start: i, End: j, Entropy: bits, Token: token})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2020, Alexander Zaytsev. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pwgen
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCheckStrength(t *testing.T) {
+	values := []struct {
+		password   string
+		weaknesses string
+		score      int
+	}{
+		{password: "", weaknesses: "", score: 0},
+		{password: "password", weaknesses: "dictionary:password", score: 0},
+		{password: "P@ssw0rd", weaknesses: "dictionary:P@ssw0rd", score: 0},
+		{password: "aaaaaa", weaknesses: "repeat:aaaaaa", score: 0},
+		{password: "abcabcabc", weaknesses: "repeat:abcabcabc", score: 0},
+		{password: "abcdef", weaknesses: "sequence:abcdef", score: 0},
+		{password: "zaq1xsw2", weaknesses: "keyboard:zaq1 keyboard:xsw2", score: 0},
+		{password: "12/05/1990", weaknesses: "date:12/05/1990", score: 0},
+		{password: "Summer2019!", weaknesses: "dictionary:Summer date:2019", score: 0},
+		{password: "correcthorsebatterystaple", weaknesses: "dictionary:correct dictionary:battery dictionary:staple", score: 3},
+		{password: "xK#9vL2$mQ8!pR4z", weaknesses: "", score: 4},
+	}
+	for i, v := range values {
+		s := CheckStrength(v.password)
+		items := make([]string, len(s.Weaknesses))
+		for j, w := range s.Weaknesses {
+			if w.Token != v.password[w.Start:w.End] {
+				t.Errorf("failed case=%d: token %v", i, w)
+			}
+			items[j] = w.Kind + ":" + w.Token
+		}
+		if w := strings.Join(items, " "); w != v.weaknesses {
+			t.Errorf("failed case=%d: weaknesses %q", i, w)
+		}
+		if s.Score != v.score {
+			t.Errorf("failed case=%d: score %v, entropy %v", i, s.Score, s.Entropy)
+		}
+		if s.Length != len(v.password) || s.Lowers+s.Uppers+s.Digits+s.Symbols != s.Length {
+			t.Errorf("failed case=%d: composition %+v", i, s)
+		}
+	}
+	long := strings.Repeat("xK#9vL2$mQ8!pR4z", 20)
+	if s := CheckStrength(long); s.Score != 4 || s.Entropy < 1000 {
+		t.Errorf("unexpected long password strength %+v", s)
+	}
+}
+
+func TestRepeatMatches(t *testing.T) {
+	// only maximal repeats are returned: "aaaaaa" and "abab" ones
+	matches := repeatMatches("aaaaaaxabab")
+	if n := len(matches); n != 2 {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+	// long repeats are checked fast, they were exponential
+	for _, base := range []string{"a", "ab", "aaaaaaab"} {
+		password := strings.Repeat(base, maxCheckLength/len(base))
+		s := CheckStrength(password)
+		if len(s.Weaknesses) != 1 || s.Weaknesses[0].Kind != WeaknessRepeat || s.Weaknesses[0].End != len(password) {
+			t.Errorf("unexpected strength of %q: %+v", base, s)
+		}
+	}
+}
+
+func TestCheckPatterns(t *testing.T) {
+	dates := map[string]bool{
+		"1990": true, "2101": false, "19900512": true, "12051990": true, "120590": true,
+		"1990-05-12": true, "12.05.90": true, "12/05-90": false, "32131990": false, "12345": false,
+	}
+	for s, expected := range dates {
+		if _, ok := parseDate(s); ok != expected {
+			t.Errorf("unexpected date %v", s)
+		}
+	}
+	keyboardOnce.Do(loadKeyboard)
+	adjacent := map[string]bool{"qw": true, "q1": true, "q2": true, "qa": true, "az": true, "a!": false, "QA": true, "qe": false, "mk": true}
+	for s, expected := range adjacent {
+		if keyboardAdjacent(s[0], s[1]) != expected {
+			t.Errorf("unexpected adjacency %v", s)
+		}
+	}
+	if v := strings.Join(leetVariants("P4$$w0rd1"), ","); v != "p4$$w0rd1,passwordi,passwordl" {
+		t.Errorf("unexpected variants %v", v)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	pg, err := NewWithOptions(WithLength(12), WithAmbiguous(true), WithPolicy(Policy{MinUppers: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{
+		"xKu9vLe3mRh7":  "",
+		"password":      "less than 1 uppers; less than 1 digits; length 8 is less than 12",
+		"Il1Il1Il1Il1!": "1 disabled symbols; 12 removed chars",
+	}
+	for password, expected := range values {
+		if v := strings.Join(pg.Check(password).Violations, "; "); v != expected {
+			t.Errorf("unexpected violations of %v: %q", password, v)
+		}
+	}
+	// chars of disabled classes are reported
+	pg, err = NewWithOptions(WithLength(12), WithNoNumerals(true), WithNoCapitalize(true), WithSymbols(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values = map[string]string{
+		"xkuwvlehmrh!": "",
+		"xKu9vLe3mRh7": "less than 1 symbols; 3 disabled uppers; 3 disabled digits",
+	}
+	for password, expected := range values {
+		if v := strings.Join(pg.Check(password).Violations, "; "); v != expected {
+			t.Errorf("unexpected violations of %v with disabled classes: %q", password, v)
+		}
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	input := "password\nxK#9vL2$mQ8!pR4z\r\n"
+	values := []struct {
+		format   string
+		expected string
+	}{
+		{format: FormatText, expected: "1: score 0, 1.00 bits, weaknesses: dictionary[0:8], " +
+			"violations: less than 1 uppers; less than 1 digits\n2: score 4, 104.87 bits, violations: 3 disabled symbols\n"},
+		{format: FormatNDJSON, expected: `{"line":1,"length":8,"lowers":8,"uppers":0,"digits":0,"symbols":0,` +
+			`"entropy":1,"score":0,"weaknesses":[{"kind":"dictionary","start":0,"end":8,"entropy":1}],` +
+			`"violations":["less than 1 uppers","less than 1 digits"]}` + "\n" + `{"line":2,"length":16,"lowers":5,"uppers":4,` +
+			`"digits":4,"symbols":3,"entropy":104.87,"score":4,"violations":["3 disabled symbols"]}` + "\n"},
+	}
+	for i, v := range values {
+		pg, err := NewWithOptions(WithPolicy(Policy{MinUppers: 1}), WithFormat(v.format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		n, err := pg.CheckAll(strings.NewReader(input), &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// symbols are disabled
+		if n != 2 {
+			t.Errorf("failed case=%d: unexpected violations %v", i, n)
+		}
+		if s := b.String(); s != v.expected {
+			t.Errorf("failed case=%d: unexpected output %q", i, s)
+		}
+	}
+	pg, err := NewWithOptions(WithFormat(FormatJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if _, err = pg.CheckAll(strings.NewReader(input), &b); err != nil {
+		t.Fatal(err)
+	}
+	var results []CheckResult
+	if err = json.Unmarshal(b.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(results); n != 2 || results[1].Line != 2 || results[1].Score != 4 {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if strings.Contains(b.String(), "password\"") {
+		t.Errorf("disclosed password %v", b.String())
+	}
+	pg, err = NewWithOptions(WithFormat(FormatCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if _, err = pg.CheckAll(strings.NewReader(input), &b); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rows); n != 3 || rows[1][8] != "dictionary[0:8]" || rows[2][7] != "4" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestCheckNoDisclosure(t *testing.T) {
+	// every violation is caused by the password chars which are not used by reports
+	const password = "QQQQ~^"
+	policy := Policy{MaxConsecutive: 2, ForbiddenFirst: "Q", MinDigits: 1}
+	for _, format := range []string{FormatText, FormatJSON, FormatNDJSON, FormatCSV} {
+		pg, err := NewWithOptions(WithPolicy(policy), WithRemoveChars("Q"), WithFormat(format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		n, err := pg.CheckAll(strings.NewReader(password+"\n"), &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("%v: unexpected violations %v", format, n)
+		}
+		s := b.String()
+		if !strings.Contains(s, "2 disabled symbols") || !strings.Contains(s, "4 removed chars") ||
+			!strings.Contains(s, "more than 2 consecutive chars at 0") || !strings.Contains(s, "forbidden first char") {
+			t.Errorf("%v: unexpected report %q", format, s)
+		}
+		if strings.ContainsAny(s, password) {
+			t.Errorf("%v: disclosed password chars %q", format, s)
+		}
+	}
+}